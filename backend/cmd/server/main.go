@@ -1,19 +1,32 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/FahmiYoshikage/linkmy-v2/internal/captcha"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/config"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/database"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/handlers"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/screening"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
+// How long user states are cached for access token checks, i.e. how long a
+// ban takes to reach other instances
+const tokenStateTTL = 30 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.Load()
@@ -30,11 +43,30 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// URL screening: blocklist + periodic rescan of existing links
+	screener := screening.New()
+	if cfg.ScreeningBlocklistFile != "" {
+		if err := screener.LoadFile(cfg.ScreeningBlocklistFile); err != nil {
+			log.Fatalf("Failed to load screening blocklist: %v", err)
+		}
+	}
+	go screener.Run(context.Background(), repository.NewLinkRepository(db),
+		time.Duration(cfg.ScreeningIntervalMinutes)*time.Minute)
+
+	// Asset storage for uploaded images, and removal of orphaned ones
+	store, err := newStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize asset storage: %v", err)
+	}
+	assetGC := storage.NewCollector(store, repository.NewAssetRepository(db))
+	go assetGC.Run(context.Background(), time.Duration(cfg.AssetGCIntervalMinutes)*time.Minute)
+
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
 		AppName:      "LinkMy API v2.0",
 		ServerHeader: "LinkMy",
 		ErrorHandler: handlers.ErrorHandler,
+		BodyLimit:    12 * 1024 * 1024, // Image uploads
 	})
 
 	// Global middleware
@@ -44,7 +76,7 @@ func main() {
 	}))
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.CORSOrigins,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Profile-Token, X-Captcha-Token",
 		AllowMethods:     "GET, POST, PUT, PATCH, DELETE, OPTIONS",
 		AllowCredentials: true,
 	}))
@@ -57,11 +89,19 @@ func main() {
 		})
 	})
 
+	// Locally stored assets. Keys change with the content, so they never go stale.
+	if local, ok := store.(*storage.Local); ok {
+		app.Static("/assets", local.Dir(), fiber.Static{MaxAge: 365 * 24 * 60 * 60})
+	}
+
 	// API routes
 	api := app.Group("/api/v1")
 
+	// Access tokens are checked against the current state of their user
+	tokens := middleware.NewTokenChecker(repository.NewUserRepository(db).GetTokenState, tokenStateTTL)
+
 	// Public routes
-	authHandler := handlers.NewAuthHandler(db, cfg)
+	authHandler := handlers.NewAuthHandler(db, cfg, tokens)
 	api.Post("/auth/register", authHandler.Register)
 	api.Post("/auth/login", authHandler.Login)
 	api.Post("/auth/refresh", authHandler.RefreshToken)
@@ -73,19 +113,29 @@ func main() {
 	api.Post("/auth/complete-registration", authHandler.CompleteRegistration)
 
 	// Public profile view
-	profileHandler := handlers.NewProfileHandler(db)
+	profileHandler := handlers.NewProfileHandler(db, cfg, store, assetGC)
 	api.Get("/p/:slug", profileHandler.GetPublicProfile)
+	unlockPerIP, unlockPerProfile := profileHandler.UnlockRateLimit(false)
+	api.Post("/p/:slug/unlock", unlockPerIP, unlockPerProfile, profileHandler.UnlockProfile)
+	api.Get("/preview/:token", profileHandler.GetPreview)
+	api.Get("/slugs/:slug/availability", profileHandler.CheckSlugAvailability)
 
 	// Click tracking (public)
-	linkHandler := handlers.NewLinkHandler(db)
+	linkHandler := handlers.NewLinkHandler(db, cfg, screener)
 	api.Post("/click/:id", linkHandler.TrackClick)
 
+	// Abuse reports (rate limited per IP unless a captcha is solved)
+	reportHandler := handlers.NewReportHandler(db, captcha.New(cfg.CaptchaSecret, cfg.CaptchaVerifyURL))
+	api.Post("/report", reportHandler.RateLimit(cfg.ReportRateLimit), reportHandler.CreateReport)
+
 	// Protected routes
-	protected := api.Group("/", middleware.JWTAuth(cfg.JWTSecret))
+	protected := api.Group("/", middleware.JWTAuth(cfg.JWTSecret, tokens), authHandler.AuditImpersonation)
 
 	// User routes
 	protected.Get("/me", authHandler.GetCurrentUser)
 	protected.Put("/me", authHandler.UpdateCurrentUser)
+	protected.Put("/me/password", authHandler.ChangePassword)
+	protected.Get("/me/activity", authHandler.GetActivity)
 
 	// Profile management
 	protected.Get("/profiles", profileHandler.GetUserProfiles)
@@ -94,6 +144,19 @@ func main() {
 	protected.Put("/profiles/:id", profileHandler.UpdateProfile)
 	protected.Delete("/profiles/:id", profileHandler.DeleteProfile)
 
+	// Draft and publish
+	protected.Post("/profiles/:id/publish", profileHandler.Publish)
+	protected.Post("/profiles/:id/publish/rollback", profileHandler.RollbackPublication)
+	protected.Get("/profiles/:id/publications", profileHandler.GetPublications)
+	protected.Post("/profiles/:id/preview", profileHandler.CreatePreviewToken)
+
+	// Image and font uploads
+	uploadHandler := handlers.NewUploadHandler(db, store)
+	protected.Post("/profiles/:id/avatar", uploadHandler.UploadAvatar)
+	protected.Post("/profiles/:profileId/theme/background", uploadHandler.UploadBackground)
+	protected.Post("/profiles/:profileId/theme/font", uploadHandler.UploadFont)
+	protected.Delete("/profiles/:profileId/theme/font", uploadHandler.DeleteFont)
+
 	// Link management
 	protected.Get("/profiles/:profileId/links", linkHandler.GetLinks)
 	protected.Post("/profiles/:profileId/links", linkHandler.CreateLink)
@@ -101,6 +164,23 @@ func main() {
 	protected.Delete("/links/:id", linkHandler.DeleteLink)
 	protected.Put("/links/reorder", linkHandler.ReorderLinks)
 
+	// A/B split variants
+	protected.Get("/links/:id/variants", linkHandler.GetVariants)
+	protected.Post("/links/:id/variants", linkHandler.CreateVariant)
+	protected.Put("/variants/:id", linkHandler.UpdateVariant)
+	protected.Delete("/variants/:id", linkHandler.DeleteVariant)
+
+	// Geo/device/language targeting rules
+	protected.Get("/links/:id/rules", linkHandler.GetRules)
+	protected.Post("/links/:id/rules", linkHandler.CreateRule)
+	protected.Put("/rules/:id", linkHandler.UpdateRule)
+	protected.Delete("/rules/:id", linkHandler.DeleteRule)
+
+	// QR codes
+	qrHandler := handlers.NewQRHandler(db, cfg, store)
+	protected.Get("/profiles/:id/qr", qrHandler.GetProfileQR)
+	protected.Get("/links/:id/qr", qrHandler.GetLinkQR)
+
 	// Category management
 	categoryHandler := handlers.NewCategoryHandler(db)
 	protected.Get("/profiles/:profileId/categories", categoryHandler.GetCategories)
@@ -112,20 +192,44 @@ func main() {
 	themeHandler := handlers.NewThemeHandler(db)
 	protected.Get("/profiles/:profileId/theme", themeHandler.GetTheme)
 	protected.Put("/profiles/:profileId/theme", themeHandler.UpdateTheme)
+	protected.Post("/profiles/:profileId/theme/apply-preset", themeHandler.ApplyPreset)
+	protected.Get("/profiles/:profileId/theme/history", themeHandler.GetHistory)
+	protected.Post("/profiles/:profileId/theme/restore/:version", themeHandler.RestoreVersion)
+	protected.Get("/themes/presets", themeHandler.GetPresets)
+	protected.Post("/themes/presets", themeHandler.CreatePreset)
+	protected.Delete("/themes/presets/:id", themeHandler.DeletePreset)
 
 	// Analytics
 	analyticsHandler := handlers.NewAnalyticsHandler(db)
 	protected.Get("/profiles/:profileId/analytics", analyticsHandler.GetProfileAnalytics)
+	protected.Get("/links/:id/analytics", analyticsHandler.GetLinkAnalytics)
 
-	// Admin routes (requires JWT + admin check)
-	adminHandler := handlers.NewAdminHandler(db)
-	admin := api.Group("/admin", middleware.JWTAuth(cfg.JWTSecret), middleware.AdminAuth())
-	admin.Get("/stats", adminHandler.GetStats)
-	admin.Get("/users", adminHandler.ListUsers)
-	admin.Get("/users/:id", adminHandler.GetUserDetail)
-	admin.Put("/users/:id", adminHandler.UpdateUser)
-	admin.Get("/profiles", adminHandler.ListProfiles)
-	admin.Put("/profiles/:id", adminHandler.UpdateProfile)
+	// Admin routes (requires JWT + a permission per route, see models.Perm*)
+	adminHandler := handlers.NewAdminHandler(db, cfg, tokens)
+	admin := api.Group("/admin", middleware.JWTAuth(cfg.JWTSecret, tokens))
+	admin.Get("/stats", middleware.RequirePermission(models.PermStatsView), adminHandler.GetStats)
+	admin.Get("/users", middleware.RequirePermission(models.PermUsersView), adminHandler.ListUsers)
+	admin.Get("/users/:id", middleware.RequirePermission(models.PermUsersView), adminHandler.GetUserDetail)
+	admin.Put("/users/:id", middleware.RequirePermission(models.PermUsersManage), adminHandler.UpdateUser)
+	admin.Post("/users/:id/impersonate", middleware.RequirePermission(models.PermUsersImpersonate), adminHandler.Impersonate)
+	admin.Get("/roles", middleware.RequirePermission(models.PermUsersView), adminHandler.ListRoles)
+	admin.Get("/profiles", middleware.RequirePermission(models.PermProfilesView), adminHandler.ListProfiles)
+	admin.Put("/profiles/:id", middleware.RequirePermission(models.PermProfilesModerate), adminHandler.UpdateProfile)
+	admin.Get("/links/flagged", middleware.RequirePermission(models.PermLinksReview), adminHandler.ListFlaggedLinks)
+	admin.Put("/links/:id/screening", middleware.RequirePermission(models.PermLinksReview), adminHandler.ReviewLink)
+	admin.Get("/audit", middleware.RequirePermission(models.PermAuditView), adminHandler.ListAuditEvents)
+	admin.Get("/reports", middleware.RequirePermission(models.PermReportsReview), adminHandler.ListReports)
+	admin.Get("/reports/:id", middleware.RequirePermission(models.PermReportsReview), adminHandler.GetReport)
+	admin.Put("/reports/:id", middleware.RequirePermission(models.PermReportsReview), adminHandler.ResolveReport)
+
+	// Server-rendered public pages. Registered last: /:slug matches any
+	// single-segment path not claimed by a route above.
+	app.Get("/r/:id", linkHandler.Redirect)
+	app.Get("/og/:slug.png", profileHandler.GetProfileOGImage)
+	app.Get("/preview/:token", profileHandler.RenderPreviewPage)
+	app.Get("/:slug", profileHandler.RenderProfilePage)
+	pageUnlockPerIP, pageUnlockPerProfile := profileHandler.UnlockRateLimit(true)
+	app.Post("/:slug", pageUnlockPerIP, pageUnlockPerProfile, profileHandler.UnlockProfilePage)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -138,3 +242,26 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// newStorage creates the configured asset storage backend
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case "local":
+		assetsURL := cfg.AssetsURL
+		if assetsURL == "" {
+			assetsURL = strings.TrimRight(cfg.BaseURL, "/") + "/assets"
+		}
+		return storage.NewLocal(cfg.StorageDir, assetsURL)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.AssetsURL,
+		})
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+}
@@ -0,0 +1,8 @@
+-- 003_profile_visibility.sql
+-- Profile visibility modes: public, unlisted, password-protected
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) DEFAULT 'public';
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255);
+
+UPDATE profiles SET visibility = 'public' WHERE visibility IS NULL;
+ALTER TABLE profiles ALTER COLUMN visibility SET NOT NULL;
@@ -0,0 +1,22 @@
+-- 004_link_variants.sql
+-- A/B split links: weighted destination variants per link
+
+CREATE TABLE IF NOT EXISTS link_variants (
+    id SERIAL PRIMARY KEY,
+    link_id INTEGER NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    label VARCHAR(50),
+    url VARCHAR(500) NOT NULL,
+    weight INTEGER NOT NULL DEFAULT 1 CHECK (weight >= 0),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ
+);
+
+-- How a variant is chosen: 'weighted' (random per click) or 'sticky' (stable per visitor)
+ALTER TABLE links ADD COLUMN IF NOT EXISTS split_mode VARCHAR(20) DEFAULT 'weighted';
+
+-- Attribute each click to the variant it was sent to
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES link_variants(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_link_variants_link ON link_variants(link_id);
+CREATE INDEX IF NOT EXISTS idx_clicks_variant ON clicks(variant_id) WHERE variant_id IS NOT NULL;
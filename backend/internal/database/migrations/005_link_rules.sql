@@ -0,0 +1,16 @@
+-- 005_link_rules.sql
+-- Geo-, device- and language-targeted redirect rules per link
+
+CREATE TABLE IF NOT EXISTS link_rules (
+    id SERIAL PRIMARY KEY,
+    link_id INTEGER NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    rule_type VARCHAR(20) NOT NULL, -- 'country', 'os' or 'language'
+    match_values TEXT[] NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    priority INTEGER DEFAULT 0,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_rules_link ON link_rules(link_id);
@@ -0,0 +1,12 @@
+-- 006_link_screening.sql
+-- Malicious URL / phishing screening of link destinations
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS screening_status VARCHAR(20) NOT NULL DEFAULT 'pending'; -- 'pending', 'clean', 'flagged', 'blocked' or 'approved'
+ALTER TABLE links ADD COLUMN IF NOT EXISTS screening_reasons TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS screened_at TIMESTAMPTZ;
+
+-- Admin review queue
+CREATE INDEX IF NOT EXISTS idx_links_screening_held ON links(screening_status) WHERE screening_status IN ('flagged', 'blocked');
+
+-- Periodic rescan picks the least recently screened links first
+CREATE INDEX IF NOT EXISTS idx_links_screened_at ON links(screened_at NULLS FIRST) WHERE screening_status IN ('pending', 'clean');
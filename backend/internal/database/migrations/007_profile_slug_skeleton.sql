@@ -0,0 +1,11 @@
+-- 007_profile_slug_skeleton.sql
+-- Lookalike detection for profile slugs: "john_doe", "j0hn-doe" and "johndoe"
+-- share a skeleton, so only one user can hold them.
+-- Keep the expression in sync with slugpolicy.Skeleton.
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS slug_skeleton VARCHAR(50)
+    GENERATED ALWAYS AS (
+        translate(lower(slug), '0134578-_', 'oleastb')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_profiles_slug_skeleton ON profiles(slug_skeleton);
@@ -0,0 +1,19 @@
+-- 008_profile_slug_history.sql
+-- Slugs released by renamed or deleted profiles: old links redirect to the
+-- profile's current slug, and the slug is quarantined for a while so nobody
+-- else can take over its traffic.
+
+CREATE TABLE IF NOT EXISTS profile_slug_history (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL,
+    slug_skeleton VARCHAR(50) GENERATED ALWAYS AS (
+        translate(lower(slug), '0134578-_', 'oleastb')
+    ) STORED,
+    profile_id INTEGER REFERENCES profiles(id) ON DELETE SET NULL, -- NULL once the profile is deleted
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,       -- Former owner, may reclaim it during quarantine
+    released_at TIMESTAMPTZ DEFAULT NOW(),
+    quarantine_until TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_slug_history_slug ON profile_slug_history(slug, released_at DESC);
+CREATE INDEX IF NOT EXISTS idx_slug_history_skeleton ON profile_slug_history(slug_skeleton, quarantine_until);
@@ -0,0 +1,16 @@
+-- 009_traffic_source.sql
+-- Where visits come from (e.g. 'qr' for scanned QR codes) and profile page views
+
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS source VARCHAR(20);
+
+CREATE TABLE IF NOT EXISTS profile_views (
+    id BIGSERIAL PRIMARY KEY,
+    profile_id INTEGER NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    source VARCHAR(20),
+    country VARCHAR(50),
+    user_agent TEXT,
+    referrer TEXT,
+    viewed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_views_profile ON profile_views(profile_id, viewed_at);
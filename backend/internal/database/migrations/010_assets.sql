@@ -0,0 +1,20 @@
+-- 010_assets.sql
+-- Uploaded avatars and background images. The file lives in asset storage
+-- under storage_key; rows outlive their profile so orphaned files can be
+-- found and removed.
+
+CREATE TABLE IF NOT EXISTS assets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    profile_id INTEGER REFERENCES profiles(id) ON DELETE SET NULL, -- NULL once the profile is deleted
+    kind VARCHAR(20) NOT NULL,                                     -- 'avatar' or 'background'
+    storage_key VARCHAR(255) UNIQUE NOT NULL,
+    url TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assets_profile ON assets(profile_id, kind);
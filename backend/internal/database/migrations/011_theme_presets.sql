@@ -0,0 +1,30 @@
+-- 011_theme_presets.sql
+-- Theme presets: built-in ones (no user) offered to everyone, plus presets
+-- users saved from their own themes. New profiles start with the default
+-- preset.
+
+CREATE TABLE IF NOT EXISTS theme_presets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE, -- NULL for built-in presets
+    slug VARCHAR(50) UNIQUE,                                -- Built-in presets only
+    name VARCHAR(100) NOT NULL,
+    settings JSONB NOT NULL,                                -- models.ThemeSettings
+    is_default BOOLEAN DEFAULT false,
+    display_order INTEGER DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_theme_presets_user ON theme_presets(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_theme_presets_default ON theme_presets(is_default) WHERE is_default;
+
+INSERT INTO theme_presets (slug, name, is_default, display_order, settings) VALUES
+    ('purple-dream', 'Purple Dream', true, 1, '{"bg_type": "gradient", "bg_value": "linear-gradient(135deg, #667eea 0%, #764ba2 100%)", "button_style": "rounded", "button_color": "#667eea", "text_color": "#333333", "font": "Inter", "layout": "centered", "container_style": "wide", "enable_animations": true, "enable_glass_effect": false, "shadow_intensity": "medium", "boxed_enabled": false, "boxed_outer_bg_type": "gradient", "boxed_container_bg": "#ffffff", "boxed_max_width": 480, "boxed_radius": 30, "boxed_shadow": true}'),
+    ('sunset', 'Sunset', false, 2, '{"bg_type": "gradient", "bg_value": "linear-gradient(135deg, #f093fb 0%, #f5576c 100%)", "button_style": "pill", "button_color": "#f5576c", "text_color": "#333333", "font": "Poppins", "layout": "centered", "container_style": "wide", "enable_animations": true, "enable_glass_effect": false, "shadow_intensity": "medium", "boxed_enabled": false, "boxed_outer_bg_type": "gradient", "boxed_container_bg": "#ffffff", "boxed_max_width": 480, "boxed_radius": 30, "boxed_shadow": true}'),
+    ('ocean', 'Ocean', false, 3, '{"bg_type": "gradient", "bg_value": "linear-gradient(135deg, #4facfe 0%, #00f2fe 100%)", "button_style": "rounded", "button_color": "#0077b6", "text_color": "#1b263b", "font": "Nunito", "layout": "centered", "container_style": "wide", "enable_animations": true, "enable_glass_effect": false, "shadow_intensity": "medium", "boxed_enabled": false, "boxed_outer_bg_type": "gradient", "boxed_container_bg": "#ffffff", "boxed_max_width": 480, "boxed_radius": 30, "boxed_shadow": true}'),
+    ('forest', 'Forest', false, 4, '{"bg_type": "gradient", "bg_value": "linear-gradient(135deg, #11998e 0%, #38ef7d 100%)", "button_style": "soft", "button_color": "#11998e", "text_color": "#1b4332", "font": "DM Sans", "layout": "centered", "container_style": "wide", "enable_animations": true, "enable_glass_effect": false, "shadow_intensity": "medium", "boxed_enabled": false, "boxed_outer_bg_type": "gradient", "boxed_container_bg": "#ffffff", "boxed_max_width": 480, "boxed_radius": 30, "boxed_shadow": true}'),
+    ('night', 'Night', false, 5, '{"bg_type": "gradient", "bg_value": "linear-gradient(135deg, #232526 0%, #414345 100%)", "button_style": "outline", "button_color": "#ffffff", "text_color": "#f5f5f5", "font": "Space Grotesk", "layout": "centered", "container_style": "wide", "enable_animations": true, "enable_glass_effect": true, "shadow_intensity": "none", "boxed_enabled": false, "boxed_outer_bg_type": "gradient", "boxed_container_bg": "#ffffff", "boxed_max_width": 480, "boxed_radius": 30, "boxed_shadow": true}'),
+    ('cotton-candy', 'Cotton Candy', false, 6, '{"bg_type": "gradient", "bg_value": "linear-gradient(135deg, #a8edea 0%, #fed6e3 100%)", "button_style": "pill", "button_color": "#ff8fab", "text_color": "#4a4e69", "font": "Outfit", "layout": "centered", "container_style": "wide", "enable_animations": true, "enable_glass_effect": false, "shadow_intensity": "light", "boxed_enabled": false, "boxed_outer_bg_type": "gradient", "boxed_container_bg": "#ffffff", "boxed_max_width": 480, "boxed_radius": 30, "boxed_shadow": true}'),
+    ('minimal', 'Minimal', false, 7, '{"bg_type": "solid", "bg_value": "#ffffff", "button_style": "square", "button_color": "#111111", "text_color": "#111111", "font": "Inter", "layout": "centered", "container_style": "wide", "enable_animations": false, "enable_glass_effect": false, "shadow_intensity": "none", "boxed_enabled": false, "boxed_outer_bg_type": "gradient", "boxed_container_bg": "#ffffff", "boxed_max_width": 480, "boxed_radius": 30, "boxed_shadow": true}'),
+    ('editorial', 'Editorial', false, 8, '{"bg_type": "solid", "bg_value": "#f4efe6", "button_style": "outline", "button_color": "#3d2c1e", "text_color": "#3d2c1e", "font": "Playfair Display", "layout": "centered", "container_style": "wide", "enable_animations": true, "enable_glass_effect": false, "shadow_intensity": "light", "boxed_enabled": true, "boxed_outer_bg_type": "solid", "boxed_outer_bg_value": "#e8dfd0", "boxed_container_bg": "#fbf8f3", "boxed_max_width": 480, "boxed_radius": 8, "boxed_shadow": true}')
+ON CONFLICT (slug) DO NOTHING;
@@ -0,0 +1,8 @@
+-- 012_custom_themes.sql
+-- Premium accounts may add custom CSS and an uploaded font to their themes.
+-- Both are sanitized before they are stored and again when pages render.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_premium BOOLEAN DEFAULT false;
+
+ALTER TABLE themes ADD COLUMN IF NOT EXISTS custom_css TEXT;
+ALTER TABLE themes ADD COLUMN IF NOT EXISTS custom_font_url TEXT; -- URL of a 'font' asset
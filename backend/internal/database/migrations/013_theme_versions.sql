@@ -0,0 +1,20 @@
+-- 013_theme_versions.sql
+-- Every change of a theme is kept as a snapshot so users can restore an
+-- earlier version. Only the latest versions of each profile are retained.
+
+CREATE TABLE IF NOT EXISTS theme_versions (
+    id BIGSERIAL PRIMARY KEY,
+    profile_id INTEGER NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,                 -- Counts up per profile
+    settings JSONB NOT NULL,                  -- models.ThemeSettings
+    source VARCHAR(20) NOT NULL,              -- 'initial', 'create', 'update', 'preset', 'upload', 'restore'
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (profile_id, version)
+);
+
+-- Existing themes start their history with their current state. Theme
+-- columns are named like the JSON fields of models.ThemeSettings.
+INSERT INTO theme_versions (profile_id, version, settings, source)
+SELECT t.profile_id, 1, to_jsonb(t) - 'id' - 'profile_id' - 'created_at' - 'updated_at', 'initial'
+FROM themes t
+ON CONFLICT (profile_id, version) DO NOTHING;
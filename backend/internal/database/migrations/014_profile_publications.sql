@@ -0,0 +1,16 @@
+-- 014_profile_publications.sql
+-- Published snapshots of profiles. Once a profile has been published,
+-- visitors see its live snapshot while edits to the profile, its links,
+-- categories and theme stay a draft until the next publish. Earlier
+-- snapshots are kept for rollback.
+
+CREATE TABLE IF NOT EXISTS profile_publications (
+    id BIGSERIAL PRIMARY KEY,
+    profile_id INTEGER NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    content JSONB NOT NULL,                   -- models.PublishedContent
+    is_live BOOLEAN NOT NULL DEFAULT false,
+    published_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_publications_profile ON profile_publications(profile_id, id DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_publications_live ON profile_publications(profile_id) WHERE is_live;
@@ -0,0 +1,21 @@
+-- 015_audit_events.sql
+-- Audit log of changes to accounts, profiles and links: who did what to
+-- whose data, with the changed fields and where the request came from.
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,       -- NULL for deleted users
+    target_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Owner of the changed data
+    action VARCHAR(50) NOT NULL,                                    -- e.g. 'profile.update'
+    target_type VARCHAR(30) NOT NULL,                               -- 'user', 'profile' or 'link'
+    target_id INTEGER NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',                            -- {"field": {"from": ..., "to": ...}}
+    ip INET,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_user ON audit_events(target_user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id);
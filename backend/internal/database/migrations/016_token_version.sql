@@ -0,0 +1,6 @@
+-- 016_token_version.sql
+-- Access tokens carry the token version of their user. Bumping it (on bans,
+-- loss of admin rights and password changes) invalidates every access token
+-- issued before.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
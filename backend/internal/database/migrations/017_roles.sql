@@ -0,0 +1,41 @@
+-- 017_roles.sql
+-- Staff roles with fine-grained admin permissions. Admins (users.is_admin)
+-- have every permission; other users get the permissions of their role,
+-- if any. Permission names match the models.Perm constants.
+
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role_id INTEGER REFERENCES roles(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role_id) WHERE role_id IS NOT NULL;
+
+INSERT INTO roles (name, description) VALUES
+    ('moderator', 'Reviews flagged links and hides abusive profiles'),
+    ('support', 'Looks up users, profiles and their activity without changing them')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r
+JOIN (VALUES
+    ('moderator', 'stats.view'),
+    ('moderator', 'users.view'),
+    ('moderator', 'profiles.view'),
+    ('moderator', 'profiles.moderate'),
+    ('moderator', 'links.review'),
+    ('support', 'stats.view'),
+    ('support', 'users.view'),
+    ('support', 'profiles.view'),
+    ('support', 'audit.view')
+) AS p(role, permission) ON p.role = r.name
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,29 @@
+-- 018_reports.sql
+-- Abuse reports about profiles and links, filed by visitors and worked
+-- through by moderators. A report is open until it is resolved, with an
+-- action taken against the target, or dismissed.
+
+CREATE TABLE IF NOT EXISTS reports (
+    id BIGSERIAL PRIMARY KEY,
+    target_type VARCHAR(10) NOT NULL CHECK (target_type IN ('profile', 'link')),
+    target_id INTEGER NOT NULL,
+    -- The reported profile, or the profile of the reported link
+    profile_id INTEGER REFERENCES profiles(id) ON DELETE SET NULL,
+    reason VARCHAR(30) NOT NULL,
+    details TEXT,
+    reporter_email VARCHAR(255), -- Notified when the report is closed
+    reporter_ip INET,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    action VARCHAR(20) NOT NULL DEFAULT 'none',
+    resolution_note TEXT,
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status, id DESC);
+CREATE INDEX IF NOT EXISTS idx_reports_target ON reports(target_type, target_id);
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'reports.review' FROM roles WHERE name = 'moderator'
+ON CONFLICT DO NOTHING;
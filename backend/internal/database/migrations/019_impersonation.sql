@@ -0,0 +1,7 @@
+-- 019_impersonation.sql
+-- Support staff can sign in as a user to see what they see. Impersonation
+-- tokens are short-lived and read-only unless an admin asks otherwise.
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'users.impersonate' FROM roles WHERE name = 'support'
+ON CONFLICT DO NOTHING;
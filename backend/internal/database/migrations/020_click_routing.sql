@@ -0,0 +1,10 @@
+-- 020_click_routing.sql
+-- Record how each click was routed. variant_id alone cannot tell clicks sent
+-- to the link's URL from clicks of targeting rules or of deleted variants.
+
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS target VARCHAR(10);  -- 'link', 'variant' or 'rule'; NULL for older clicks
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS rule_id INTEGER REFERENCES link_rules(id) ON DELETE SET NULL;
+
+UPDATE clicks SET target = 'variant' WHERE target IS NULL AND variant_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_clicks_rule ON clicks(rule_id) WHERE rule_id IS NOT NULL;
@@ -2,19 +2,41 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/FahmiYoshikage/linkmy-v2/internal/config"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/pagination"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type AdminHandler struct {
-	db *pgxpool.Pool
+	db          *pgxpool.Pool
+	cfg         *config.Config
+	userRepo    *repository.UserRepository
+	profileRepo *repository.ProfileRepository
+	auditRepo   *repository.AuditRepository
+	roleRepo    *repository.RoleRepository
+	reportRepo  *repository.ReportRepository
+	tokens      *middleware.TokenChecker
 }
 
-func NewAdminHandler(db *pgxpool.Pool) *AdminHandler {
-	return &AdminHandler{db: db}
+func NewAdminHandler(db *pgxpool.Pool, cfg *config.Config, tokens *middleware.TokenChecker) *AdminHandler {
+	return &AdminHandler{
+		db:          db,
+		cfg:         cfg,
+		userRepo:    repository.NewUserRepository(db),
+		profileRepo: repository.NewProfileRepository(db),
+		auditRepo:   repository.NewAuditRepository(db),
+		roleRepo:    repository.NewRoleRepository(db),
+		reportRepo:  repository.NewReportRepository(db),
+		tokens:      tokens,
+	}
 }
 
 // Dashboard stats
@@ -26,6 +48,7 @@ type AdminStats struct {
 	TotalClicks     int `json:"total_clicks"`
 	NewUsersWeek    int `json:"new_users_week"`
 	ActiveUsersWeek int `json:"active_users_week"`
+	FlaggedLinks    int `json:"flagged_links"`
 }
 
 // GetStats returns dashboard statistics
@@ -54,6 +77,9 @@ func (h *AdminHandler) GetStats(c *fiber.Ctx) error {
 	// Active users (logged in this week - approximate via sessions)
 	h.db.QueryRow(ctx, "SELECT COUNT(DISTINCT user_id) FROM sessions WHERE created_at > NOW() - INTERVAL '7 days'").Scan(&stats.ActiveUsersWeek)
 
+	// Links held by URL screening
+	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM links WHERE screening_status = 'flagged'").Scan(&stats.FlaggedLinks)
+
 	return SuccessResponse(c, stats)
 }
 
@@ -65,101 +91,110 @@ type AdminUser struct {
 	IsVerified bool       `json:"is_verified"`
 	IsActive   bool       `json:"is_active"`
 	IsAdmin    bool       `json:"is_admin"`
+	IsPremium  bool       `json:"is_premium"`
+	Role       *string    `json:"role,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
 	ProfileCount int      `json:"profile_count"`
 	TotalClicks  int      `json:"total_clicks"`
 }
 
-// ListUsers returns all users for admin
+// Sort keys of the admin user list
+var adminUserSorts = map[string]pagination.Sort{
+	"created_at": {Column: "created_at", Type: "timestamptz"},
+	"clicks":     {Column: "total_clicks", Type: "bigint"},
+	"profiles":   {Column: "profile_count", Type: "bigint"},
+}
+
+// ListUsers returns users for admin, a page at a time. Filters: search
+// (username or email), verified, active, admin (true/false), from and to
+// (sign-up time).
 func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
-	ctx := context.Background()
-	
-	search := c.Query("search", "")
-	
-	query := `
-		SELECT u.id, u.username, u.email, u.is_verified, u.is_active, u.is_admin, u.created_at,
+	page, err := pagination.FromQuery(c, adminUserSorts, "created_at")
+	if err != nil {
+		return paginationError(c, err)
+	}
+
+	var where pagination.Conditions
+	if search := c.Query("search"); search != "" {
+		where.Add("(u.username ILIKE '%' || ? || '%' OR u.email ILIKE '%' || ? || '%')", search)
+	}
+	errs := addFlagFilters(c, &where,
+		flagFilter{"verified", "u.is_verified"}, flagFilter{"active", "u.is_active"}, flagFilter{"admin", "u.is_admin"})
+	errs = append(errs, addTimeFilters(c, &where, "u.created_at")...)
+	if len(errs) > 0 {
+		return ValidationFailed(c, errs)
+	}
+
+	inner := `
+		SELECT u.id, u.username, u.email, u.is_verified, u.is_active, u.is_admin, u.is_premium, r.name AS role, u.created_at,
 			COALESCE((SELECT COUNT(*) FROM profiles WHERE user_id = u.id), 0) as profile_count,
 			COALESCE((SELECT SUM(l.clicks) FROM profiles p JOIN links l ON l.profile_id = p.id WHERE p.user_id = u.id), 0) as total_clicks
 		FROM users u
-		WHERE ($1 = '' OR u.username ILIKE '%' || $1 || '%' OR u.email ILIKE '%' || $1 || '%')
-		ORDER BY u.created_at DESC
-		LIMIT 100
-	`
-	
-	rows, err := h.db.Query(ctx, query, search)
+		LEFT JOIN roles r ON r.id = u.role_id
+		` + where.SQL()
+
+	ctx := context.Background()
+
+	var total int
+	if err := h.db.QueryRow(ctx, pagination.CountQuery(inner), where.Args...).Scan(&total); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	query, args := page.Query(inner, adminUserSorts, where.Args)
+	rows, err := h.db.Query(ctx, query, args...)
 	if err != nil {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
 	}
 	defer rows.Close()
-	
+
 	users := []AdminUser{}
 	for rows.Next() {
 		var u AdminUser
-		err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.IsVerified, &u.IsActive, &u.IsAdmin, &u.CreatedAt, &u.ProfileCount, &u.TotalClicks)
+		err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.IsVerified, &u.IsActive, &u.IsAdmin, &u.IsPremium, &u.Role, &u.CreatedAt, &u.ProfileCount, &u.TotalClicks)
 		if err != nil {
-			continue
+			return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
 		}
 		users = append(users, u)
 	}
-	
-	return SuccessResponse(c, users)
+
+	return SuccessResponse(c, pagination.NewPage(page, users, total, func(u AdminUser) (interface{}, int64) {
+		switch page.Sort {
+		case "clicks":
+			return u.TotalClicks, int64(u.ID)
+		case "profiles":
+			return u.ProfileCount, int64(u.ID)
+		}
+		return u.CreatedAt, int64(u.ID)
+	}))
 }
 
-// UpdateUser updates user status (verify, ban, admin)
+// UpdateUser updates user status (verify, ban, premium) and, with the
+// roles.grant permission, admin rights and role. Banning a user revokes their
+// sessions; the last active admin cannot be demoted.
 func (h *AdminHandler) UpdateUser(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return ValidationError(c, "Invalid user ID")
 	}
 	
-	var req struct {
-		IsVerified *bool `json:"is_verified"`
-		IsActive   *bool `json:"is_active"`
-		IsAdmin    *bool `json:"is_admin"`
-	}
+	var req models.AdminUpdateUserRequest
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
-	
-	ctx := context.Background()
-	
-	// Build dynamic update query
-	updates := []string{}
-	args := []interface{}{}
-	argNum := 1
-	
-	if req.IsVerified != nil {
-		updates = append(updates, "is_verified = $"+string(rune('0'+argNum)))
-		args = append(args, *req.IsVerified)
-		argNum++
-	}
-	if req.IsActive != nil {
-		updates = append(updates, "is_active = $"+string(rune('0'+argNum)))
-		args = append(args, *req.IsActive)
-		argNum++
-	}
-	if req.IsAdmin != nil {
-		updates = append(updates, "is_admin = $"+string(rune('0'+argNum)))
-		args = append(args, *req.IsAdmin)
-		argNum++
-	}
-	
-	if len(updates) == 0 {
-		return ValidationError(c, "No updates provided")
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
 	}
 	
-	// Simple approach - update each field individually
-	if req.IsVerified != nil {
-		h.db.Exec(ctx, "UPDATE users SET is_verified = $1 WHERE id = $2", *req.IsVerified, id)
+	if req.IsVerified == nil && req.IsActive == nil && req.IsAdmin == nil && req.IsPremium == nil && req.Role == nil {
+		return InvalidField(c, "", "required", "No updates provided")
 	}
-	if req.IsActive != nil {
-		h.db.Exec(ctx, "UPDATE users SET is_active = $1 WHERE id = $2", *req.IsActive, id)
-	}
-	if req.IsAdmin != nil {
-		h.db.Exec(ctx, "UPDATE users SET is_admin = $1 WHERE id = $2", *req.IsAdmin, id)
+
+	after, err := h.updateUser(c, id, req)
+	if err != nil {
+		return actionError(c, err)
 	}
-	
-	return SuccessResponse(c, fiber.Map{"message": "User updated"})
+
+	return SuccessResponse(c, after)
 }
 
 // Profile list for admin
@@ -175,41 +210,77 @@ type AdminProfile struct {
 	CreatedAt  time.Time `json:"created_at"`
 }
 
-// ListProfiles returns all profiles for admin moderation
+// Sort keys of the admin profile list
+var adminProfileSorts = map[string]pagination.Sort{
+	"created_at": {Column: "created_at", Type: "timestamptz"},
+	"clicks":     {Column: "total_clicks", Type: "bigint"},
+	"links":      {Column: "link_count", Type: "bigint"},
+}
+
+// ListProfiles returns profiles for admin moderation, a page at a time.
+// Filters: search (name, slug or username), user_id, active (true/false),
+// from and to (creation time).
 func (h *AdminHandler) ListProfiles(c *fiber.Ctx) error {
-	ctx := context.Background()
-	
-	search := c.Query("search", "")
-	
-	query := `
+	page, err := pagination.FromQuery(c, adminProfileSorts, "created_at")
+	if err != nil {
+		return paginationError(c, err)
+	}
+
+	var where pagination.Conditions
+	if search := c.Query("search"); search != "" {
+		where.Add("(p.name ILIKE '%' || ? || '%' OR p.slug ILIKE '%' || ? || '%' OR u.username ILIKE '%' || ? || '%')", search)
+	}
+	if userID := c.QueryInt("user_id"); userID != 0 {
+		where.Add("p.user_id = ?", userID)
+	}
+	errs := addFlagFilters(c, &where, flagFilter{"active", "p.is_active"})
+	errs = append(errs, addTimeFilters(c, &where, "p.created_at")...)
+	if len(errs) > 0 {
+		return ValidationFailed(c, errs)
+	}
+
+	inner := `
 		SELECT p.id, p.user_id, u.username, p.slug, p.name, p.is_active,
 			COALESCE((SELECT COUNT(*) FROM links WHERE profile_id = p.id), 0) as link_count,
 			COALESCE((SELECT SUM(clicks) FROM links WHERE profile_id = p.id), 0) as total_clicks,
 			p.created_at
 		FROM profiles p
 		JOIN users u ON u.id = p.user_id
-		WHERE ($1 = '' OR p.name ILIKE '%' || $1 || '%' OR p.slug ILIKE '%' || $1 || '%' OR u.username ILIKE '%' || $1 || '%')
-		ORDER BY p.created_at DESC
-		LIMIT 100
-	`
-	
-	rows, err := h.db.Query(ctx, query, search)
+		` + where.SQL()
+
+	ctx := context.Background()
+
+	var total int
+	if err := h.db.QueryRow(ctx, pagination.CountQuery(inner), where.Args...).Scan(&total); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	query, args := page.Query(inner, adminProfileSorts, where.Args)
+	rows, err := h.db.Query(ctx, query, args...)
 	if err != nil {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
 	}
 	defer rows.Close()
-	
+
 	profiles := []AdminProfile{}
 	for rows.Next() {
 		var p AdminProfile
 		err := rows.Scan(&p.ID, &p.UserID, &p.Username, &p.Slug, &p.Name, &p.IsActive, &p.LinkCount, &p.TotalClicks, &p.CreatedAt)
 		if err != nil {
-			continue
+			return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
 		}
 		profiles = append(profiles, p)
 	}
-	
-	return SuccessResponse(c, profiles)
+
+	return SuccessResponse(c, pagination.NewPage(page, profiles, total, func(p AdminProfile) (interface{}, int64) {
+		switch page.Sort {
+		case "clicks":
+			return p.TotalClicks, int64(p.ID)
+		case "links":
+			return p.LinkCount, int64(p.ID)
+		}
+		return p.CreatedAt, int64(p.ID)
+	}))
 }
 
 // UpdateProfile updates profile status (hide/show)
@@ -219,17 +290,18 @@ func (h *AdminHandler) UpdateProfile(c *fiber.Ctx) error {
 		return ValidationError(c, "Invalid profile ID")
 	}
 	
-	var req struct {
-		IsActive *bool `json:"is_active"`
-	}
+	var req models.AdminUpdateProfileRequest
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
-	
-	ctx := context.Background()
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 	
 	if req.IsActive != nil {
-		h.db.Exec(ctx, "UPDATE profiles SET is_active = $1 WHERE id = $2", *req.IsActive, id)
+		if err := h.setProfileActive(c, id, *req.IsActive); err != nil {
+			return actionError(c, err)
+		}
 	}
 	
 	return SuccessResponse(c, fiber.Map{"message": "Profile updated"})
@@ -250,10 +322,11 @@ func (h *AdminHandler) GetUserDetail(c *fiber.Ctx) error {
 		ProfileCount int `json:"profile_count"`
 	}
 	err = h.db.QueryRow(ctx, `
-		SELECT id, username, email, is_verified, is_active, is_admin, created_at,
+		SELECT id, username, email, is_verified, is_active, is_admin, is_premium,
+			(SELECT name FROM roles WHERE roles.id = users.role_id), created_at,
 			(SELECT COUNT(*) FROM profiles WHERE user_id = $1) as profile_count
 		FROM users WHERE id = $1
-	`, id).Scan(&user.ID, &user.Username, &user.Email, &user.IsVerified, &user.IsActive, &user.IsAdmin, &user.CreatedAt, &user.ProfileCount)
+	`, id).Scan(&user.ID, &user.Username, &user.Email, &user.IsVerified, &user.IsActive, &user.IsAdmin, &user.IsPremium, &user.Role, &user.CreatedAt, &user.ProfileCount)
 	
 	if err != nil {
 		return NotFound(c, "User")
@@ -290,3 +363,278 @@ func (h *AdminHandler) GetUserDetail(c *fiber.Ctx) error {
 		"profiles": profiles,
 	})
 }
+
+// Link held by URL screening, for the admin review queue
+type AdminFlaggedLink struct {
+	ID               int        `json:"id"`
+	ProfileID        int        `json:"profile_id"`
+	ProfileSlug      string     `json:"profile_slug"`
+	Username         string     `json:"username"`
+	Title            string     `json:"title"`
+	URL              string     `json:"url"`
+	ScreeningStatus  string     `json:"screening_status"`
+	ScreeningReasons []string   `json:"screening_reasons"`
+	ScreenedAt       *time.Time `json:"screened_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// ListFlaggedLinks returns the links held by URL screening. Pass
+// ?status=blocked to see blocked links instead of those awaiting review.
+func (h *AdminHandler) ListFlaggedLinks(c *fiber.Ctx) error {
+	ctx := context.Background()
+
+	status := c.Query("status", models.ScreeningFlagged)
+	if status != models.ScreeningFlagged && status != models.ScreeningBlocked {
+		return InvalidField(c, "status", "oneof", "status must be one of: flagged, blocked")
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT l.id, l.profile_id, p.slug, u.username, l.title, l.url,
+			l.screening_status, l.screening_reasons, l.screened_at, l.created_at
+		FROM links l
+		JOIN profiles p ON p.id = l.profile_id
+		JOIN users u ON u.id = p.user_id
+		WHERE l.screening_status = $1
+		ORDER BY l.screened_at DESC NULLS LAST
+		LIMIT 100
+	`, status)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+	defer rows.Close()
+
+	links := []AdminFlaggedLink{}
+	for rows.Next() {
+		var l AdminFlaggedLink
+		err := rows.Scan(&l.ID, &l.ProfileID, &l.ProfileSlug, &l.Username, &l.Title, &l.URL,
+			&l.ScreeningStatus, &l.ScreeningReasons, &l.ScreenedAt, &l.CreatedAt)
+		if err != nil {
+			continue
+		}
+		links = append(links, l)
+	}
+
+	return SuccessResponse(c, links)
+}
+
+// ReviewLink resolves a link held by URL screening. Approving puts it back
+// online and exempts it from heuristics until its URL changes; blocking keeps
+// it offline for good.
+func (h *AdminHandler) ReviewLink(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid link ID")
+	}
+
+	var req models.AdminReviewLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	status, err := h.reviewLink(c, id, req.Action)
+	if err != nil {
+		return actionError(c, err)
+	}
+
+	return SuccessResponse(c, fiber.Map{"message": "Link " + status, "screening_status": status})
+}
+
+// ListRoles returns the staff roles and their permissions. Admins have every
+// permission.
+func (h *AdminHandler) ListRoles(c *fiber.Ctx) error {
+	roles, err := h.roleRepo.List(context.Background())
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	return SuccessResponse(c, fiber.Map{
+		"roles":       roles,
+		"permissions": models.AllPermissions,
+	})
+}
+
+// Helper: Update a user as an admin and record the change. Failures are
+// *fiber.Error values, see actionError.
+func (h *AdminHandler) updateUser(c *fiber.Ctx, id int, req models.AdminUpdateUserRequest) (*models.User, error) {
+	// Granting admin rights and roles takes its own permission
+	if (req.IsAdmin != nil || req.Role != nil) && !middleware.HasPermission(c, models.PermRolesGrant) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Permission required: "+models.PermRolesGrant)
+	}
+
+	// Admins cannot lock themselves out
+	if id == middleware.GetUserID(c) && ((req.IsAdmin != nil && !*req.IsAdmin) || (req.IsActive != nil && !*req.IsActive)) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "You cannot remove your own admin rights or disable your own account")
+	}
+
+	ctx := context.Background()
+
+	// Staff cannot grant more than they hold themselves: admin rights take
+	// an admin, and a role takes every permission in it
+	if !middleware.IsAdmin(c) {
+		if req.IsAdmin != nil && *req.IsAdmin {
+			return nil, fiber.NewError(fiber.StatusForbidden, "Only admins can grant admin rights")
+		}
+		if req.Role != nil && *req.Role != "" {
+			role, err := h.roleRepo.GetByName(ctx, *req.Role)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return nil, &fieldError{Field: "role", Rule: "exists", Message: "role does not exist"}
+				}
+				return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to update user")
+			}
+			for _, permission := range role.Permissions {
+				if !middleware.HasPermission(c, permission) {
+					return nil, fiber.NewError(fiber.StatusForbidden, "You cannot grant a role with permissions you lack")
+				}
+			}
+		}
+
+		// Nor change users who hold a permission they lack, so only admins
+		// can change admins
+		target, err := h.userRepo.GetTokenState(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+			}
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to update user")
+		}
+		if target.IsAdmin {
+			return nil, fiber.NewError(fiber.StatusForbidden, "Only admins can change admins")
+		}
+		for _, permission := range target.Permissions {
+			if !middleware.HasPermission(c, permission) {
+				return nil, fiber.NewError(fiber.StatusForbidden, "You cannot change a user with permissions you lack")
+			}
+		}
+	}
+
+	before, after, err := h.userRepo.AdminUpdate(ctx, id, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		if errors.Is(err, repository.ErrUnknownRole) {
+			return nil, &fieldError{Field: "role", Rule: "exists", Message: "role does not exist"}
+		}
+		if errors.Is(err, repository.ErrLastAdmin) {
+			return nil, fiber.NewError(fiber.StatusConflict, "Cannot remove the last active admin")
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to update user")
+	}
+	h.tokens.Forget(id)
+
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &id,
+		Action:       models.AuditUserUpdate,
+		TargetType:   models.AuditTargetUser,
+		TargetID:     id,
+		Changes:      auditChanges(before, after),
+	})
+
+	return after, nil
+}
+
+// Helper: Hide or show a profile and record the change
+func (h *AdminHandler) setProfileActive(c *fiber.Ctx, id int, active bool) error {
+	ctx := context.Background()
+
+	profile, err := h.profileRepo.GetByID(ctx, id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Profile not found")
+	}
+	if profile.IsActive == active {
+		return nil
+	}
+
+	if _, err := h.db.Exec(ctx, "UPDATE profiles SET is_active = $1 WHERE id = $2", active, id); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update profile")
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &profile.UserID,
+		Action:       models.AuditProfileUpdate,
+		TargetType:   models.AuditTargetProfile,
+		TargetID:     id,
+		Changes: map[string]models.AuditChange{
+			"is_active": {From: profile.IsActive, To: active},
+		},
+	})
+	return nil
+}
+
+// Helper: Approve or block a link and record the review. Returns the new
+// screening status.
+func (h *AdminHandler) reviewLink(c *fiber.Ctx, id int, action string) (string, error) {
+	ctx := context.Background()
+
+	status, isActive := models.ScreeningApproved, true
+	if action == "block" {
+		status, isActive = models.ScreeningBlocked, false
+	}
+
+	// The previous state and owner of the link, for the audit log
+	var oldStatus string
+	var oldActive bool
+	var ownerID int
+	err := h.db.QueryRow(ctx, `
+		SELECT l.screening_status, l.is_active, p.user_id
+		FROM links l JOIN profiles p ON p.id = l.profile_id
+		WHERE l.id = $1
+	`, id).Scan(&oldStatus, &oldActive, &ownerID)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusNotFound, "Link not found")
+	}
+
+	result, err := h.db.Exec(ctx, `
+		UPDATE links SET screening_status = $1, is_active = $2, screened_at = NOW()
+		WHERE id = $3
+	`, status, isActive, id)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusInternalServerError, "Failed to update link")
+	}
+	if result.RowsAffected() == 0 {
+		return "", fiber.NewError(fiber.StatusNotFound, "Link not found")
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &ownerID,
+		Action:       models.AuditLinkReview,
+		TargetType:   models.AuditTargetLink,
+		TargetID:     id,
+		Changes: auditChanges(
+			fiber.Map{"screening_status": oldStatus, "is_active": oldActive},
+			fiber.Map{"screening_status": status, "is_active": isActive},
+		),
+	})
+	return status, nil
+}
+
+// fieldError is an admin action error caused by a request field
+type fieldError models.FieldError
+
+func (e *fieldError) Error() string {
+	return e.Message
+}
+
+// Helper: Respond with the error of an admin action
+func actionError(c *fiber.Ctx, err error) error {
+	var field *fieldError
+	if errors.As(err, &field) {
+		return ValidationFailed(c, []models.FieldError{models.FieldError(*field)})
+	}
+	var e *fiber.Error
+	if !errors.As(err, &e) {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+	switch e.Code {
+	case fiber.StatusNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   "not_found",
+			"message": e.Message,
+		})
+	}
+	return ErrorResponse(c, e.Code, e.Message)
+}
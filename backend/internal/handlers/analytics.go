@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
@@ -14,12 +15,14 @@ import (
 type AnalyticsHandler struct {
 	db          *pgxpool.Pool
 	profileRepo *repository.ProfileRepository
+	linkRepo    *repository.LinkRepository
 }
 
 func NewAnalyticsHandler(db *pgxpool.Pool) *AnalyticsHandler {
 	return &AnalyticsHandler{
 		db:          db,
 		profileRepo: repository.NewProfileRepository(db),
+		linkRepo:    repository.NewLinkRepository(db),
 	}
 }
 
@@ -128,5 +131,195 @@ func (h *AnalyticsHandler) GetProfileAnalytics(c *fiber.Ctx) error {
 		}
 	}
 
+	// Clicks by source (e.g. scanned QR codes)
+	analytics.ClicksBySource = h.sourceStats(ctx, `
+		SELECT COALESCE(c.source, 'direct') as source, COUNT(*) as clicks
+		FROM clicks c
+		JOIN links l ON c.link_id = l.id
+		WHERE l.profile_id = $1 AND c.clicked_at >= $2
+		GROUP BY c.source
+		ORDER BY clicks DESC
+	`, profileID, startDate)
+
+	// Profile page views
+	h.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM profile_views WHERE profile_id = $1 AND viewed_at >= $2
+	`, profileID, startDate).Scan(&analytics.TotalViews)
+	analytics.ViewsBySource = h.sourceStats(ctx, `
+		SELECT COALESCE(source, 'direct') as source, COUNT(*) as views
+		FROM profile_views
+		WHERE profile_id = $1 AND viewed_at >= $2
+		GROUP BY source
+		ORDER BY views DESC
+	`, profileID, startDate)
+
 	return SuccessResponse(c, analytics)
 }
+
+// GetLinkAnalytics returns analytics for a single link, including A/B split results
+func (h *AnalyticsHandler) GetLinkAnalytics(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid link ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	link, err := h.linkRepo.GetByID(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+
+	// Get time range from query params (default: last 30 days)
+	days := c.QueryInt("days", 30)
+	startDate := time.Now().AddDate(0, 0, -days)
+
+	analytics := &models.LinkAnalyticsResponse{
+		LinkID:      link.ID,
+		Title:       link.Title,
+		ClicksByDay: []models.DayStats{},
+		Variants:    []models.VariantStats{},
+	}
+
+	// Total clicks in range
+	h.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM clicks WHERE link_id = $1 AND clicked_at >= $2
+	`, linkID, startDate).Scan(&analytics.TotalClicks)
+
+	// Clicks by day
+	rows, err := h.db.Query(ctx, `
+		SELECT DATE(clicked_at) as date, COUNT(*) as clicks
+		FROM clicks
+		WHERE link_id = $1 AND clicked_at >= $2
+		GROUP BY DATE(clicked_at)
+		ORDER BY date ASC
+	`, linkID, startDate)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var ds models.DayStats
+			var date time.Time
+			rows.Scan(&date, &ds.Clicks)
+			ds.Date = date.Format("2006-01-02")
+			analytics.ClicksByDay = append(analytics.ClicksByDay, ds)
+		}
+	}
+
+	// Clicks by source
+	analytics.ClicksBySource = h.sourceStats(ctx, `
+		SELECT COALESCE(source, 'direct') as source, COUNT(*) as clicks
+		FROM clicks
+		WHERE link_id = $1 AND clicked_at >= $2
+		GROUP BY source
+		ORDER BY clicks DESC
+	`, linkID, startDate)
+
+	// Clicks sent to the link's own URL
+	if n := h.countClicks(ctx, linkID, startDate, "target = 'link'"); n > 0 {
+		analytics.Variants = append(analytics.Variants, models.VariantStats{
+			Kind:   models.ClickTargetLink,
+			URL:    link.URL,
+			Clicks: n,
+		})
+	}
+
+	// Clicks per variant
+	rows, err = h.db.Query(ctx, `
+		SELECT v.id, v.label, v.url, v.weight, COUNT(c.id) as clicks
+		FROM link_variants v
+		LEFT JOIN clicks c ON c.variant_id = v.id AND c.clicked_at >= $2
+		WHERE v.link_id = $1
+		GROUP BY v.id
+		ORDER BY v.id ASC
+	`, linkID, startDate)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			vs := models.VariantStats{Kind: models.ClickTargetVariant}
+			var variantID int
+			rows.Scan(&variantID, &vs.Label, &vs.URL, &vs.Weight, &vs.Clicks)
+			vs.VariantID = &variantID
+			analytics.Variants = append(analytics.Variants, vs)
+		}
+	}
+
+	// Clicks per targeting rule
+	rows, err = h.db.Query(ctx, `
+		SELECT r.id, r.rule_type, r.match_values, r.url, COUNT(c.id) as clicks
+		FROM link_rules r
+		LEFT JOIN clicks c ON c.rule_id = r.id AND c.clicked_at >= $2
+		WHERE r.link_id = $1
+		GROUP BY r.id
+		ORDER BY r.priority DESC, r.id ASC
+	`, linkID, startDate)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			vs := models.VariantStats{Kind: models.ClickTargetRule}
+			var ruleID int
+			var ruleType string
+			var values []string
+			rows.Scan(&ruleID, &ruleType, &values, &vs.URL, &vs.Clicks)
+			label := ruleType + ": " + strings.Join(values, ", ")
+			vs.RuleID, vs.Label = &ruleID, &label
+			analytics.Variants = append(analytics.Variants, vs)
+		}
+	}
+
+	// Clicks whose variant or rule no longer exists, and clicks recorded
+	// before clicks kept their route; their destination is not known
+	for _, bucket := range []struct {
+		kind, cond string
+	}{
+		{models.DestinationDeletedVariants, "target = 'variant' AND variant_id IS NULL"},
+		{models.DestinationDeletedRules, "target = 'rule' AND rule_id IS NULL"},
+		{models.DestinationUnknown, "target IS NULL"},
+	} {
+		if n := h.countClicks(ctx, linkID, startDate, bucket.cond); n > 0 {
+			analytics.Variants = append(analytics.Variants, models.VariantStats{Kind: bucket.kind, Clicks: n})
+		}
+	}
+
+	if analytics.TotalClicks > 0 {
+		for i := range analytics.Variants {
+			analytics.Variants[i].Share = float64(analytics.Variants[i].Clicks) * 100 / float64(analytics.TotalClicks)
+		}
+	}
+
+	return SuccessResponse(c, analytics)
+}
+
+// Helper: Count the clicks of a link since start that match cond
+func (h *AnalyticsHandler) countClicks(ctx context.Context, linkID int, start time.Time, cond string) int {
+	var n int
+	h.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM clicks
+		WHERE link_id = $1 AND clicked_at >= $2 AND `+cond, linkID, start).Scan(&n)
+	return n
+}
+
+// Helper: Run a (source, count) breakdown query
+func (h *AnalyticsHandler) sourceStats(ctx context.Context, query string, args ...interface{}) []models.SourceStats {
+	stats := []models.SourceStats{}
+	rows, err := h.db.Query(ctx, query, args...)
+	if err != nil {
+		return stats
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ss models.SourceStats
+		rows.Scan(&ss.Source, &ss.Count)
+		stats = append(stats, ss)
+	}
+	return stats
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/pagination"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Shown instead of secrets such as password hashes
+const auditRedacted = "[redacted]"
+
+// GetActivity returns the audit events of changes to the current user's
+// account and profiles, newest first, a page at a time
+func (h *AuthHandler) GetActivity(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	page, err := pagination.FromQuery(c, repository.AuditSorts, "created_at")
+	if err != nil {
+		return paginationError(c, err)
+	}
+
+	events, err := h.auditRepo.List(context.Background(), repository.AuditFilter{
+		TargetUserID: userID,
+		Action:       c.Query("action"),
+	}, page)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch activity")
+	}
+
+	activity := pagination.Page[models.ActivityEvent]{
+		Items:      make([]models.ActivityEvent, 0, len(events.Items)),
+		NextCursor: events.NextCursor,
+		Total:      events.Total,
+	}
+	for _, e := range events.Items {
+		a := models.ActivityEvent{
+			ID:         e.ID,
+			Action:     e.Action,
+			Actor:      "admin",
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			Changes:    e.Changes,
+			CreatedAt:  e.CreatedAt,
+		}
+		if e.ActorID != nil && *e.ActorID == userID {
+			a.Actor = "you"
+			a.IP = e.IP
+			a.UserAgent = e.UserAgent
+		}
+		activity.Items = append(activity.Items, a)
+	}
+
+	return SuccessResponse(c, activity)
+}
+
+// ListAuditEvents returns audit events for admin, newest first, a page at a
+// time. Filters: actor_id, user_id (whose data changed), action,
+// target_type, target_id, from and to (RFC 3339 or YYYY-MM-DD).
+func (h *AdminHandler) ListAuditEvents(c *fiber.Ctx) error {
+	page, err := pagination.FromQuery(c, repository.AuditSorts, "created_at")
+	if err != nil {
+		return paginationError(c, err)
+	}
+
+	filter := repository.AuditFilter{
+		ActorID:      c.QueryInt("actor_id"),
+		TargetUserID: c.QueryInt("user_id"),
+		Action:       c.Query("action"),
+		TargetType:   c.Query("target_type"),
+		TargetID:     c.QueryInt("target_id"),
+	}
+	var errs []models.FieldError
+	if v := c.Query("from"); v != "" {
+		if filter.From, err = parseTimeFilter(v); err != nil {
+			errs = append(errs, timeFilterError("from"))
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if filter.To, err = parseTimeFilter(v); err != nil {
+			errs = append(errs, timeFilterError("to"))
+		}
+	}
+	if len(errs) > 0 {
+		return ValidationFailed(c, errs)
+	}
+
+	events, err := h.auditRepo.List(context.Background(), filter, page)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	return SuccessResponse(c, events)
+}
+
+// Helper: Record an audit event. The actor defaults to the authenticated
+// user, or the staff member impersonating them; the client IP and user agent
+// come from the request. Failures are logged rather than failing a change
+// that has already been made.
+func recordAudit(c *fiber.Ctx, repo *repository.AuditRepository, event *models.AuditEvent) {
+	if event.ActorID == nil {
+		if impersonation := middleware.GetImpersonation(c); impersonation != nil {
+			event.ActorID = &impersonation.ActorID
+		} else if userID := middleware.GetUserID(c); userID != 0 {
+			event.ActorID = &userID
+		}
+	}
+	ip := c.IP()
+	event.IP = &ip
+	if userAgent := c.Get(fiber.HeaderUserAgent); userAgent != "" {
+		event.UserAgent = &userAgent
+	}
+
+	if err := repo.Create(context.Background(), event); err != nil {
+		log.Printf("audit: failed to record %s of %s %d: %v", event.Action, event.TargetType, event.TargetID, err)
+	}
+}
+
+// auditChanges returns the fields that differ between two versions of an
+// object, compared by their JSON form. Either may be nil, for objects that
+// were created or deleted. Timestamps maintained by the database are left
+// out.
+func auditChanges(before, after interface{}) map[string]models.AuditChange {
+	from, to := jsonFields(before), jsonFields(after)
+
+	changes := map[string]models.AuditChange{}
+	for name, value := range from {
+		if !reflect.DeepEqual(value, to[name]) {
+			changes[name] = models.AuditChange{From: value, To: to[name]}
+		}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; !ok {
+			changes[name] = models.AuditChange{To: value}
+		}
+	}
+	delete(changes, "created_at")
+	delete(changes, "updated_at")
+	return changes
+}
+
+// jsonFields returns the fields of v as encoded to JSON
+func jsonFields(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if rv := reflect.ValueOf(v); !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -21,30 +22,30 @@ type AuthHandler struct {
 	userRepo    *repository.UserRepository
 	sessionRepo *repository.SessionRepository
 	profileRepo *repository.ProfileRepository
+	auditRepo   *repository.AuditRepository
+	tokens      *middleware.TokenChecker
 	cfg         *config.Config
 }
 
-func NewAuthHandler(db *pgxpool.Pool, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(db *pgxpool.Pool, cfg *config.Config, tokens *middleware.TokenChecker) *AuthHandler {
 	return &AuthHandler{
 		userRepo:    repository.NewUserRepository(db),
 		sessionRepo: repository.NewSessionRepository(db),
 		profileRepo: repository.NewProfileRepository(db),
+		auditRepo:   repository.NewAuditRepository(db),
+		tokens:      tokens,
 		cfg:         cfg,
 	}
 }
 
 // SendOTP sends OTP to email for registration
 func (h *AuthHandler) SendOTP(c *fiber.Ctx) error {
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
+	var req models.SendOTPRequest
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
-
-	if len(req.Password) < 8 {
-		return ValidationError(c, "Password must be at least 8 characters")
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
 	}
 
 	ctx := context.Background()
@@ -75,13 +76,13 @@ func (h *AuthHandler) SendOTP(c *fiber.Ctx) error {
 
 // VerifyOTPEndpoint verifies OTP code
 func (h *AuthHandler) VerifyOTPEndpoint(c *fiber.Ctx) error {
-	var req struct {
-		Email string `json:"email"`
-		OTP   string `json:"otp"`
-	}
+	var req models.VerifyOTPRequest
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	if !VerifyOTP(req.Email, req.OTP) {
 		return ErrorResponse(c, fiber.StatusBadRequest, "Invalid or expired OTP")
@@ -95,30 +96,19 @@ func (h *AuthHandler) VerifyOTPEndpoint(c *fiber.Ctx) error {
 
 // CompleteRegistration finishes registration after OTP verification
 func (h *AuthHandler) CompleteRegistration(c *fiber.Ctx) error {
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		OTP      string `json:"otp"`
-		Username string `json:"username"`
-		Slug     string `json:"slug"`
-	}
+	var req models.CompleteRegistrationRequest
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	// Verify OTP first
 	if !VerifyOTP(req.Email, req.OTP) {
 		return ErrorResponse(c, fiber.StatusBadRequest, "Invalid or expired OTP")
 	}
 
-	// Validation
-	if len(req.Username) < 3 || len(req.Username) > 50 {
-		return ValidationError(c, "Username must be 3-50 characters")
-	}
-	if len(req.Password) < 8 {
-		return ValidationError(c, "Password must be at least 8 characters")
-	}
-
 	ctx := context.Background()
 
 	// Check if username exists
@@ -130,6 +120,16 @@ func (h *AuthHandler) CompleteRegistration(c *fiber.Ctx) error {
 		return ErrorResponse(c, fiber.StatusConflict, "Username already taken")
 	}
 
+	// Claim the requested slug before creating the account so a rejected
+	// slug doesn't leave a user without a profile
+	slug := ""
+	if req.Slug != "" {
+		slug, err = claimSlug(ctx, h.profileRepo, req.Slug, 0, 0)
+		if err != nil {
+			return slugErrorResponse(c, err)
+		}
+	}
+
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -152,21 +152,22 @@ func (h *AuthHandler) CompleteRegistration(c *fiber.Ctx) error {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create user")
 	}
 
-	// Create profile with slug
-	slug := req.Slug
+	// Create profile with slug (derived from the username if none was given)
 	if slug == "" {
-		slug = req.Username
-	}
-	profile := &models.Profile{
-		UserID:   user.ID,
-		Slug:     slug,
-		Name:     req.Username,
-		Title:    &req.Username,
-		Avatar:   "default-avatar.png",
-		IsActive: true,
-	}
-	if err := h.profileRepo.Create(ctx, profile); err != nil {
-		// Log error but don't fail
+		slug = registrationSlug(ctx, h.profileRepo, req.Username)
+	}
+	if slug != "" {
+		profile := &models.Profile{
+			UserID:   user.ID,
+			Slug:     slug,
+			Name:     req.Username,
+			Title:    &req.Username,
+			Avatar:   "default-avatar.png",
+			IsActive: true,
+		}
+		if err := h.profileRepo.Create(ctx, profile); err != nil {
+			// Log error but don't fail
+		}
 	}
 
 	// Delete OTP after successful registration
@@ -197,13 +198,8 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
-
-	// Basic validation
-	if len(req.Username) < 3 || len(req.Username) > 50 {
-		return ValidationError(c, "Username must be 3-50 characters")
-	}
-	if len(req.Password) < 8 {
-		return ValidationError(c, "Password must be at least 8 characters")
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
 	}
 
 	ctx := context.Background()
@@ -248,18 +244,20 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create user")
 	}
 
-	// Create default profile with username as slug
-	profile := &models.Profile{
-		UserID:   user.ID,
-		Slug:     req.Username,
-		Name:     req.Username + " - Main Profile",
-		Title:    &req.Username,
-		Avatar:   "default-avatar.png",
-		IsActive: true,
-	}
-	if err := h.profileRepo.Create(ctx, profile); err != nil {
-		// Log error but don't fail registration
-		// Profile can be created later
+	// Create default profile with username as slug (or a free variant of it)
+	if slug := registrationSlug(ctx, h.profileRepo, req.Username); slug != "" {
+		profile := &models.Profile{
+			UserID:   user.ID,
+			Slug:     slug,
+			Name:     req.Username + " - Main Profile",
+			Title:    &req.Username,
+			Avatar:   "default-avatar.png",
+			IsActive: true,
+		}
+		if err := h.profileRepo.Create(ctx, profile); err != nil {
+			// Log error but don't fail registration
+			// Profile can be created later
+		}
 	}
 
 	// Generate tokens
@@ -287,6 +285,9 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	ctx := context.Background()
 
@@ -320,6 +321,14 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create session")
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		ActorID:      &user.ID,
+		TargetUserID: &user.ID,
+		Action:       models.AuditLogin,
+		TargetType:   models.AuditTargetUser,
+		TargetID:     user.ID,
+	})
+
 	return SuccessResponse(c, models.AuthResponse{
 		User:         user.ToPublic(),
 		AccessToken:  accessToken,
@@ -334,6 +343,9 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	ctx := context.Background()
 
@@ -393,7 +405,10 @@ func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 		return NotFound(c, "User")
 	}
 
-	return SuccessResponse(c, user.ToPublic())
+	public := user.ToPublic()
+	public.Permissions = middleware.GetPermissions(c)
+	public.Impersonation = middleware.GetImpersonation(c)
+	return SuccessResponse(c, public)
 }
 
 // UpdateCurrentUser updates the current user's info
@@ -403,19 +418,20 @@ func (h *AuthHandler) UpdateCurrentUser(c *fiber.Ctx) error {
 		return Unauthorized(c)
 	}
 
-	var req struct {
-		Username *string `json:"username"`
-		Email    *string `json:"email"`
-	}
+	var req models.UpdateCurrentUserRequest
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	ctx := context.Background()
 	user, err := h.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return NotFound(c, "User")
 	}
+	before := *user
 
 	if req.Username != nil {
 		user.Username = *req.Username
@@ -431,16 +447,103 @@ func (h *AuthHandler) UpdateCurrentUser(c *fiber.Ctx) error {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update user")
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &user.ID,
+		Action:       models.AuditUserUpdate,
+		TargetType:   models.AuditTargetUser,
+		TargetID:     user.ID,
+		Changes:      auditChanges(&before, user),
+	})
+
 	return SuccessResponse(c, user.ToPublic())
 }
 
+// ChangePassword changes the current user's password. Every access token
+// and session of the user is revoked; the response carries new tokens for
+// the client that made the change.
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return Unauthorized(c)
+	}
+
+	// Staff acting as the user never learn or set their password
+	if middleware.GetImpersonation(c) != nil {
+		return ErrorResponse(c, fiber.StatusForbidden, "Passwords cannot be changed while impersonating")
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	ctx := context.Background()
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return NotFound(c, "User")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return ErrorResponse(c, fiber.StatusUnauthorized, "Current password is incorrect")
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to hash password")
+	}
+
+	user.TokenVersion, err = h.userRepo.UpdatePassword(ctx, userID, string(hashed))
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update password")
+	}
+	h.tokens.Forget(userID)
+	if err := h.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to revoke sessions")
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &userID,
+		Action:       models.AuditPasswordChange,
+		TargetType:   models.AuditTargetUser,
+		TargetID:     userID,
+		Changes:      map[string]models.AuditChange{"password": {From: auditRedacted, To: auditRedacted}},
+	})
+
+	accessToken, err := h.generateAccessToken(user)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	refreshToken, err := h.createSession(ctx, user, c)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create session")
+	}
+
+	return SuccessResponse(c, models.AuthResponse{
+		User:         user.ToPublic(),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.cfg.JWTExpiryHours * 3600),
+	})
+}
+
 // Helper: Generate access token
 func (h *AuthHandler) generateAccessToken(user *models.User) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
 		"email":    user.Email,
 		"is_admin": user.IsAdmin,
+		"tv":       user.TokenVersion,
+		"jti":      jti,
 		"exp":      time.Now().Add(time.Duration(h.cfg.JWTExpiryHours) * time.Hour).Unix(),
 		"iat":      time.Now().Unix(),
 	}
@@ -449,6 +552,15 @@ func (h *AuthHandler) generateAccessToken(user *models.User) (string, error) {
 	return token.SignedString([]byte(h.cfg.JWTSecret))
 }
 
+// Helper: Generate the unique ID (jti) of an access token
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
 // Helper: Create session with refresh token
 func (h *AuthHandler) createSession(ctx context.Context, user *models.User, c *fiber.Ctx) (string, error) {
 	// Generate refresh token
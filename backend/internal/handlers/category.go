@@ -6,6 +6,7 @@ import (
 	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -66,9 +67,8 @@ func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
-
-	if req.Name == "" {
-		return ValidationError(c, "Name is required")
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
 	}
 
 	icon := "bi-folder"
@@ -122,6 +122,9 @@ func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	// Build updated category (simplified)
 	category = &models.Category{ID: categoryID}
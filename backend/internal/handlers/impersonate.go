@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Lifetime of impersonation tokens. They cannot be refreshed.
+const impersonationTTL = 15 * time.Minute
+
+// Impersonate issues an access token to act as a user, so support staff see
+// exactly what they see. The token names the staff member in its act claim,
+// is read-only unless an admin asks for write access, and every request made
+// with it is recorded in the audit log.
+func (h *AdminHandler) Impersonate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid user ID")
+	}
+
+	var req models.AdminImpersonateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return ValidationError(c, "Invalid request body")
+		}
+		if errs := validation.Struct(req); errs != nil {
+			return ValidationFailed(c, errs)
+		}
+	}
+
+	actorID := middleware.GetUserID(c)
+	if id == actorID {
+		return InvalidField(c, "id", "self", "You cannot impersonate yourself")
+	}
+	if req.Write && !middleware.IsAdmin(c) {
+		return ErrorResponse(c, fiber.StatusForbidden, "Only admins can impersonate with write access")
+	}
+
+	ctx := context.Background()
+
+	// Disabled users are not found
+	user, err := h.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return NotFound(c, "User")
+	}
+	if user.IsAdmin {
+		return ErrorResponse(c, fiber.StatusForbidden, "Admins cannot be impersonated")
+	}
+
+	actor, err := h.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return Unauthorized(c)
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+	expiresAt := time.Now().Add(impersonationTTL)
+	claims := jwt.MapClaims{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"is_admin": false,
+		"tv":       user.TokenVersion,
+		"jti":      jti,
+		"exp":      expiresAt.Unix(),
+		"iat":      time.Now().Unix(),
+		// The actor's own token version, so that signing them out everywhere
+		// also ends their impersonations
+		"act": jwt.MapClaims{
+			"user_id":  actor.ID,
+			"username": actor.Username,
+			"tv":       actor.TokenVersion,
+			"write":    req.Write,
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.cfg.JWTSecret))
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	changes := map[string]models.AuditChange{"write": {To: req.Write}}
+	if req.Reason != nil {
+		changes["reason"] = models.AuditChange{To: *req.Reason}
+	}
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &user.ID,
+		Action:       models.AuditImpersonate,
+		TargetType:   models.AuditTargetUser,
+		TargetID:     user.ID,
+		Changes:      changes,
+	})
+
+	return SuccessResponse(c, fiber.Map{
+		"access_token": accessToken,
+		"expires_at":   expiresAt,
+		"read_only":    !req.Write,
+		"user":         user.ToPublic(),
+	})
+}
+
+// AuditImpersonation records every request made with an impersonation token,
+// with its method, path and response status (use after JWTAuth middleware)
+func (h *AuthHandler) AuditImpersonation(c *fiber.Ctx) error {
+	impersonation := middleware.GetImpersonation(c)
+	if impersonation == nil {
+		return c.Next()
+	}
+
+	err := c.Next()
+
+	userID := middleware.GetUserID(c)
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &userID,
+		Action:       models.AuditImpersonated,
+		TargetType:   models.AuditTargetUser,
+		TargetID:     userID,
+		Changes: map[string]models.AuditChange{
+			"request": {To: c.Method() + " " + c.Path()},
+			"status":  {To: c.Response().StatusCode()},
+		},
+	})
+	return err
+}
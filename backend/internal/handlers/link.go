@@ -3,23 +3,42 @@ package handlers
 import (
 	"context"
 	"errors"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/FahmiYoshikage/linkmy-v2/internal/config"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/linkurl"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/screening"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var sourcePattern = regexp.MustCompile(`^[a-z0-9_-]{1,20}$`)
+
 type LinkHandler struct {
 	linkRepo    *repository.LinkRepository
+	variantRepo *repository.LinkVariantRepository
+	ruleRepo    *repository.LinkRuleRepository
 	profileRepo *repository.ProfileRepository
+	pubRepo     *repository.PublicationRepository
+	screener    *screening.Screener
+	cfg         *config.Config
 }
 
-func NewLinkHandler(db *pgxpool.Pool) *LinkHandler {
+func NewLinkHandler(db *pgxpool.Pool, cfg *config.Config, screener *screening.Screener) *LinkHandler {
 	return &LinkHandler{
 		linkRepo:    repository.NewLinkRepository(db),
+		variantRepo: repository.NewLinkVariantRepository(db),
+		ruleRepo:    repository.NewLinkRuleRepository(db),
 		profileRepo: repository.NewProfileRepository(db),
+		pubRepo:     repository.NewPublicationRepository(db),
+		screener:    screener,
+		cfg:         cfg,
 	}
 }
 
@@ -67,9 +86,20 @@ func (h *LinkHandler) CreateLink(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	normalizedURL, err := linkurl.Normalize(req.URL)
+	if err != nil {
+		return ValidationFailed(c, []models.FieldError{urlFieldError("url", err)})
+	}
 
-	if req.Title == "" || req.URL == "" {
-		return ValidationError(c, "Title and URL are required")
+	// Blocklisted destinations are refused; suspicious ones are saved but
+	// held for admin review
+	result := h.screener.Screen(ctx, normalizedURL)
+	if result.Status == models.ScreeningBlocked {
+		return ValidationFailed(c, []models.FieldError{screeningFieldError("url", result)})
 	}
 
 	icon := "bi-link-45deg"
@@ -81,7 +111,7 @@ func (h *LinkHandler) CreateLink(c *fiber.Ctx) error {
 		ProfileID:  profileID,
 		CategoryID: req.CategoryID,
 		Title:      req.Title,
-		URL:        req.URL,
+		URL:        normalizedURL,
 		Icon:       icon,
 		IsActive:   true,
 	}
@@ -89,6 +119,7 @@ func (h *LinkHandler) CreateLink(c *fiber.Ctx) error {
 	if req.Position != nil {
 		link.Position = *req.Position
 	}
+	applyScreening(link, result)
 
 	if err := h.linkRepo.Create(ctx, link); err != nil {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create link")
@@ -128,13 +159,27 @@ func (h *LinkHandler) UpdateLink(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	// Update fields if provided
 	if req.Title != nil {
 		link.Title = *req.Title
 	}
 	if req.URL != nil {
-		link.URL = *req.URL
+		normalizedURL, err := linkurl.Normalize(*req.URL)
+		if err != nil {
+			return ValidationFailed(c, []models.FieldError{urlFieldError("url", err)})
+		}
+		if normalizedURL != link.URL {
+			result := h.screener.Screen(ctx, normalizedURL)
+			if result.Status == models.ScreeningBlocked {
+				return ValidationFailed(c, []models.FieldError{screeningFieldError("url", result)})
+			}
+			link.URL = normalizedURL
+			applyScreening(link, result)
+		}
 	}
 	if req.Icon != nil {
 		link.Icon = *req.Icon
@@ -148,6 +193,17 @@ func (h *LinkHandler) UpdateLink(c *fiber.Ctx) error {
 	if req.IsActive != nil {
 		link.IsActive = *req.IsActive
 	}
+	if req.SplitMode != nil {
+		link.SplitMode = *req.SplitMode
+	}
+
+	// Only an admin can put a held link back online
+	if link.UnderReview() {
+		if req.IsActive != nil && *req.IsActive {
+			return ErrorResponse(c, fiber.StatusForbidden, "Link is held for review by URL screening")
+		}
+		link.IsActive = false
+	}
 
 	if err := h.linkRepo.Update(ctx, link); err != nil {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update link")
@@ -190,6 +246,9 @@ func (h *LinkHandler) ReorderLinks(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	ctx := context.Background()
 
@@ -217,34 +276,203 @@ func (h *LinkHandler) TrackClick(c *fiber.Ctx) error {
 
 	var req models.TrackClickRequest
 	c.BodyParser(&req) // Optional body
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	ctx := context.Background()
 
 	// Verify link exists
 	link, err := h.linkRepo.GetByID(ctx, linkID)
+	if err == nil {
+		link, err = h.publishedLink(ctx, c, link)
+	}
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return NotFound(c, "Link")
 		}
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
 	}
+	if !link.IsActive || link.UnderReview() {
+		return NotFound(c, "Link")
+	}
+
+	// Pick the destination (targeting rule, A/B variant or the link URL)
+	visitor := newVisitorInfo(c)
+	destination, route := h.resolveDestination(ctx, c, link, visitor)
+
+	h.recordClick(ctx, c, link, route, visitor, req.Referrer, trafficSource(req.Source))
 
+	return SuccessResponse(c, fiber.Map{
+		"url": destination,
+	})
+}
+
+// Redirect sends a visitor to a link's destination and records the click
+// (public endpoint, used by the server-rendered profile pages)
+func (h *LinkHandler) Redirect(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	ctx := context.Background()
+
+	link, err := h.linkRepo.GetByID(ctx, linkID)
+	if err == nil {
+		link, err = h.publishedLink(ctx, c, link)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fiber.ErrNotFound
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	if !link.IsActive || link.UnderReview() {
+		return fiber.ErrNotFound
+	}
+
+	visitor := newVisitorInfo(c)
+	destination, route := h.resolveDestination(ctx, c, link, visitor)
+
+	var referrer *string
+	if ref := c.Get(fiber.HeaderReferer); ref != "" {
+		referrer = &ref
+	}
+	source := c.Query("src")
+	h.recordClick(ctx, c, link, route, visitor, referrer, trafficSource(&source))
+
+	// Destinations can differ per visitor, never cache the redirect
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Redirect(destination, fiber.StatusFound)
+}
+
+// Helper: Resolve a link as visitors of its profile see it. Links of hidden
+// profiles, banned owners and password-protected profiles the visitor has
+// not unlocked cannot be followed. Once the profile has been
+// published, only links in the live publication can be followed, with their
+// published destination. Variants, targeting rules and screening always
+// apply as they are.
+func (h *LinkHandler) publishedLink(ctx context.Context, c *fiber.Ctx, link *models.Link) (*models.Link, error) {
+	online, err := h.profileRepo.IsOnline(ctx, link.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	if !online {
+		return nil, repository.ErrNotFound
+	}
+
+	profile, err := h.profileRepo.GetByID(ctx, link.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Visibility == models.VisibilityPassword && !isProfileUnlocked(c, h.cfg.JWTSecret, profile) {
+		return nil, repository.ErrNotFound
+	}
+
+	pub, err := h.pubRepo.GetLive(ctx, link.ProfileID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return link, nil
+		}
+		return nil, err
+	}
+
+	for _, l := range pub.Content.Links {
+		if l.ID != link.ID {
+			continue
+		}
+		published := *link
+		published.URL = l.URL
+		published.IsActive = l.IsActive
+		if l.UnderReview() {
+			published.ScreeningStatus = l.ScreeningStatus
+		}
+		return &published, nil
+	}
+	// Added in the draft
+	return nil, repository.ErrNotFound
+}
+
+// Helper: Count a click and record it for analytics
+func (h *LinkHandler) recordClick(ctx context.Context, c *fiber.Ctx, link *models.Link, route clickRoute, visitor visitorInfo, referrer, source *string) {
 	// Increment click counter
-	h.linkRepo.IncrementClicks(ctx, linkID)
+	h.linkRepo.IncrementClicks(ctx, link.ID)
 
 	// Record click for analytics
 	ip := c.IP()
 	userAgent := c.Get("User-Agent")
 	click := &models.Click{
-		LinkID:    linkID,
+		LinkID:    link.ID,
+		VariantID: route.VariantID,
+		RuleID:    route.RuleID,
+		Target:    route.Target,
 		IP:        &ip,
 		UserAgent: &userAgent,
-		Referrer:  req.Referrer,
-		// Country and city would be looked up via IP geolocation service
+		Referrer:  referrer,
+		Source:    source,
+		// City would be looked up via IP geolocation service
+	}
+	if visitor.Country != "" {
+		click.Country = &visitor.Country
 	}
 	h.linkRepo.RecordClick(ctx, click)
+}
 
-	return SuccessResponse(c, fiber.Map{
-		"url": link.URL,
-	})
+// trafficSource normalizes a source tag such as "qr"; unusable tags count as
+// direct traffic
+func trafficSource(raw *string) *string {
+	if raw == nil {
+		return nil
+	}
+	source := strings.ToLower(strings.TrimSpace(*raw))
+	if !sourcePattern.MatchString(source) {
+		return nil
+	}
+	return &source
+}
+
+// Helper: Store a screening result on a link, deactivating it when held
+func applyScreening(link *models.Link, result screening.Result) {
+	now := time.Now()
+	link.ScreeningStatus = result.Status
+	link.ScreeningReasons = result.Reasons
+	link.ScreenedAt = &now
+	if result.Held() {
+		link.IsActive = false
+	}
+}
+
+// clickRoute records which destination of a link a click was sent to
+type clickRoute struct {
+	Target    string // models.ClickTarget*
+	VariantID *int
+	RuleID    *int
+}
+
+// Helper: Resolve where a click should go. Targeting rules are evaluated first,
+// then active A/B variants; the link's own URL is the default.
+func (h *LinkHandler) resolveDestination(ctx context.Context, c *fiber.Ctx, link *models.Link, visitor visitorInfo) (string, clickRoute) {
+	rules, err := h.ruleRepo.GetByLinkID(ctx, link.ID, true)
+	if err == nil {
+		if rule := matchRule(rules, visitor); rule != nil {
+			return rule.URL, clickRoute{Target: models.ClickTargetRule, RuleID: &rule.ID}
+		}
+	}
+
+	linkRoute := clickRoute{Target: models.ClickTargetLink}
+	variants, err := h.variantRepo.GetByLinkID(ctx, link.ID, true)
+	if err != nil || len(variants) == 0 {
+		return link.URL, linkRoute
+	}
+
+	visitorKey := ""
+	if link.SplitMode == models.SplitSticky {
+		visitorKey = visitorID(c)
+	}
+
+	if v := pickVariant(variants, link.SplitMode, visitorKey, link.ID); v != nil {
+		return v.URL, clickRoute{Target: models.ClickTargetVariant, VariantID: &v.ID}
+	}
+	return link.URL, linkRoute
 }
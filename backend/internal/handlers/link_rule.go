@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/linkurl"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetRules returns all targeting rules of a link
+func (h *LinkHandler) GetRules(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid link ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	rules, err := h.ruleRepo.GetByLinkID(ctx, linkID, false)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch rules")
+	}
+
+	return SuccessResponse(c, rules)
+}
+
+// CreateRule adds a targeting rule to a link
+func (h *LinkHandler) CreateRule(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid link ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	var req models.CreateLinkRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	normalizedURL, err := linkurl.Normalize(req.URL)
+	if err != nil {
+		return ValidationFailed(c, []models.FieldError{urlFieldError("url", err)})
+	}
+	if result := h.screener.Screen(ctx, normalizedURL); result.Held() {
+		return ValidationFailed(c, []models.FieldError{screeningFieldError("url", result)})
+	}
+
+	values, errs := normalizeRuleValues(req.RuleType, req.MatchValues)
+	if errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	rule := &models.LinkRule{
+		LinkID:      linkID,
+		RuleType:    req.RuleType,
+		MatchValues: values,
+		URL:         normalizedURL,
+		IsActive:    true,
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+
+	if err := h.ruleRepo.Create(ctx, rule); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create rule")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// UpdateRule updates a targeting rule
+func (h *LinkHandler) UpdateRule(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	ruleID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid rule ID")
+	}
+
+	ctx := context.Background()
+
+	rule, err := h.ruleRepo.GetByID(ctx, ruleID)
+	if err != nil {
+		return NotFound(c, "Rule")
+	}
+
+	// Check ownership through the parent link
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, rule.LinkID)
+	if err != nil {
+		return NotFound(c, "Rule")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	var req models.UpdateLinkRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	// Update fields if provided
+	if req.MatchValues != nil {
+		values, errs := normalizeRuleValues(rule.RuleType, req.MatchValues)
+		if errs != nil {
+			return ValidationFailed(c, errs)
+		}
+		rule.MatchValues = values
+	}
+	if req.URL != nil {
+		normalizedURL, err := linkurl.Normalize(*req.URL)
+		if err != nil {
+			return ValidationFailed(c, []models.FieldError{urlFieldError("url", err)})
+		}
+		if result := h.screener.Screen(ctx, normalizedURL); result.Held() {
+			return ValidationFailed(c, []models.FieldError{screeningFieldError("url", result)})
+		}
+		rule.URL = normalizedURL
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if err := h.ruleRepo.Update(ctx, rule); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update rule")
+	}
+
+	return SuccessResponse(c, rule)
+}
+
+// DeleteRule deletes a targeting rule
+func (h *LinkHandler) DeleteRule(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	ruleID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid rule ID")
+	}
+
+	ctx := context.Background()
+
+	rule, err := h.ruleRepo.GetByID(ctx, ruleID)
+	if err != nil {
+		return NotFound(c, "Rule")
+	}
+
+	// Check ownership through the parent link
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, rule.LinkID)
+	if err != nil {
+		return NotFound(c, "Rule")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	if err := h.ruleRepo.Delete(ctx, ruleID); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete rule")
+	}
+
+	return SuccessResponse(c, fiber.Map{"message": "Rule deleted"})
+}
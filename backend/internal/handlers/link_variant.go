@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	mrand "math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/linkurl"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Cookie identifying a visitor for sticky A/B assignment
+const visitorCookie = "lm_vid"
+
+// GetVariants returns all destination variants of a link
+func (h *LinkHandler) GetVariants(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid link ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	variants, err := h.variantRepo.GetByLinkID(ctx, linkID, false)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch variants")
+	}
+
+	return SuccessResponse(c, variants)
+}
+
+// CreateVariant adds a destination variant to a link
+func (h *LinkHandler) CreateVariant(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid link ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	var req models.CreateLinkVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	normalizedURL, err := linkurl.Normalize(req.URL)
+	if err != nil {
+		return ValidationFailed(c, []models.FieldError{urlFieldError("url", err)})
+	}
+	if result := h.screener.Screen(ctx, normalizedURL); result.Held() {
+		return ValidationFailed(c, []models.FieldError{screeningFieldError("url", result)})
+	}
+
+	variant := &models.LinkVariant{
+		LinkID:   linkID,
+		Label:    req.Label,
+		URL:      normalizedURL,
+		Weight:   1,
+		IsActive: true,
+	}
+	if req.Weight != nil {
+		variant.Weight = *req.Weight
+	}
+
+	if err := h.variantRepo.Create(ctx, variant); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create variant")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    variant,
+	})
+}
+
+// UpdateVariant updates a destination variant
+func (h *LinkHandler) UpdateVariant(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	variantID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid variant ID")
+	}
+
+	ctx := context.Background()
+
+	variant, err := h.variantRepo.GetByID(ctx, variantID)
+	if err != nil {
+		return NotFound(c, "Variant")
+	}
+
+	// Check ownership through the parent link
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, variant.LinkID)
+	if err != nil {
+		return NotFound(c, "Variant")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	var req models.UpdateLinkVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	// Update fields if provided
+	if req.Label != nil {
+		variant.Label = req.Label
+	}
+	if req.URL != nil {
+		normalizedURL, err := linkurl.Normalize(*req.URL)
+		if err != nil {
+			return ValidationFailed(c, []models.FieldError{urlFieldError("url", err)})
+		}
+		if result := h.screener.Screen(ctx, normalizedURL); result.Held() {
+			return ValidationFailed(c, []models.FieldError{screeningFieldError("url", result)})
+		}
+		variant.URL = normalizedURL
+	}
+	if req.Weight != nil {
+		variant.Weight = *req.Weight
+	}
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+
+	if err := h.variantRepo.Update(ctx, variant); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update variant")
+	}
+
+	return SuccessResponse(c, variant)
+}
+
+// DeleteVariant deletes a destination variant
+func (h *LinkHandler) DeleteVariant(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	variantID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid variant ID")
+	}
+
+	ctx := context.Background()
+
+	variant, err := h.variantRepo.GetByID(ctx, variantID)
+	if err != nil {
+		return NotFound(c, "Variant")
+	}
+
+	// Check ownership through the parent link
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, variant.LinkID)
+	if err != nil {
+		return NotFound(c, "Variant")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	if err := h.variantRepo.Delete(ctx, variantID); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete variant")
+	}
+
+	return SuccessResponse(c, fiber.Map{"message": "Variant deleted"})
+}
+
+// pickVariant chooses a variant proportionally to its weight. In sticky mode
+// the choice is derived from the visitor ID so repeat visits land on the same
+// variant as long as the variant set and weights are unchanged.
+func pickVariant(variants []models.LinkVariant, mode, visitor string, linkID int) *models.LinkVariant {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	var n int
+	if mode == models.SplitSticky && visitor != "" {
+		hash := fnv.New64a()
+		hash.Write([]byte(visitor + ":" + strconv.Itoa(linkID)))
+		n = int(hash.Sum64() % uint64(total))
+	} else {
+		n = mrand.IntN(total)
+	}
+
+	for i := range variants {
+		n -= variants[i].Weight
+		if n < 0 {
+			return &variants[i]
+		}
+	}
+	return nil
+}
+
+// visitorID returns the visitor's stable ID, issuing a cookie on first visit
+func visitorID(c *fiber.Ctx) string {
+	if id := c.Cookies(visitorCookie); id != "" {
+		return id
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	id := hex.EncodeToString(b)
+
+	c.Cookie(&fiber.Cookie{
+		Name:     visitorCookie,
+		Value:    id,
+		Path:     "/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return id
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/pagination"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper: Report invalid pagination parameters
+func paginationError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		return InvalidField(c, "sort", "sort", "sort is not a sort key of this list")
+	}
+	return InvalidField(c, "cursor", "cursor", "cursor is invalid")
+}
+
+// flagFilter is a true/false query parameter filtering a boolean column
+type flagFilter struct {
+	param  string
+	column string
+}
+
+// addFlagFilters adds the conditions of the flag filters given in the query
+// and returns the invalid ones
+func addFlagFilters(c *fiber.Ctx, where *pagination.Conditions, filters ...flagFilter) []models.FieldError {
+	var errs []models.FieldError
+	for _, f := range filters {
+		v := c.Query(f.param)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, models.FieldError{Field: f.param, Rule: "boolean", Message: f.param + " must be true or false"})
+			continue
+		}
+		where.Add(f.column+" = ?", b)
+	}
+	return errs
+}
+
+// addTimeFilters adds the conditions of the from and to query parameters
+// (RFC 3339 or YYYY-MM-DD) on a time column and returns the invalid ones
+func addTimeFilters(c *fiber.Ctx, where *pagination.Conditions, column string) []models.FieldError {
+	var errs []models.FieldError
+	for _, f := range []struct{ param, op string }{{"from", ">="}, {"to", "<"}} {
+		v := c.Query(f.param)
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeFilter(v)
+		if err != nil {
+			errs = append(errs, timeFilterError(f.param))
+			continue
+		}
+		where.Add(column+" "+f.op+" ?", t)
+	}
+	return errs
+}
+
+// timeFilterError reports an unreadable from or to query parameter
+func timeFilterError(param string) models.FieldError {
+	return models.FieldError{Field: param, Rule: "datetime", Message: param + " must be an RFC 3339 time or a YYYY-MM-DD date"}
+}
+
+func parseTimeFilter(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
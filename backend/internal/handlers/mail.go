@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+)
+
+// sendMail sends a plain text email through the SMTP server configured by
+// the MAIL_* environment variables
+func sendMail(toEmail, subject, body string) error {
+	smtpHost := os.Getenv("MAIL_HOST")
+	smtpPort := os.Getenv("MAIL_PORT")
+	smtpUser := os.Getenv("MAIL_USERNAME")
+	smtpPass := os.Getenv("MAIL_PASSWORD")
+	mailFrom := os.Getenv("MAIL_FROM")
+
+	if smtpHost == "" || smtpUser == "" || smtpPass == "" {
+		return fmt.Errorf("SMTP not configured: host=%s, user=%s", smtpHost, smtpUser)
+	}
+
+	if mailFrom == "" {
+		mailFrom = smtpUser
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+		mailFrom, toEmail, subject, body)
+
+	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
+
+	addr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
+	return smtp.SendMail(addr, auth, mailFrom, []string{toEmail}, []byte(msg))
+}
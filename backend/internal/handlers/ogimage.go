@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/ogimage"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Number of preview cards kept in memory
+const ogCacheSize = 500
+
+// Largest avatar decoded for a preview card
+const (
+	maxAvatarBytes  = 5 << 20
+	maxAvatarPixels = 4096 * 4096
+)
+
+// Longest wait for an avatar to be read from storage
+const avatarTimeout = 3 * time.Second
+
+// GetProfileOGImage serves the Open Graph preview card of a profile (public
+// endpoint). Cards are cached in memory and regenerated when the profile or
+// its theme changes.
+func (h *ProfileHandler) GetProfileOGImage(c *fiber.Ctx) error {
+	ctx := context.Background()
+
+	profile, err := h.profileRepo.GetBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fiber.ErrNotFound
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	// The card shows the name, title and avatar, which are kept private
+	if profile.Visibility == models.VisibilityPassword {
+		return fiber.ErrNotFound
+	}
+
+	profile, theme, err := loadPublicAppearance(ctx, h.pubRepo, h.themeRepo, profile)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	fingerprint := ogimage.Fingerprint(profile, theme)
+	etag := `"` + fingerprint + `"`
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	card, ok := h.ogCache.Get(profile.ID, fingerprint)
+	if !ok {
+		avatar, complete := loadAvatar(ctx, h.storage, profile)
+		card, err = ogimage.Render(ogimage.CardFor(profile, theme, avatar, h.profileDisplayURL(profile)))
+		if err != nil {
+			return err
+		}
+		// Retry a failed avatar read on the next request
+		if complete {
+			h.ogCache.Put(profile.ID, fingerprint, card)
+		}
+	}
+
+	c.Type("png")
+	return c.Send(card)
+}
+
+// Helper: Load and decode the avatar of a profile from asset storage. Other
+// URLs are never fetched. complete is false if the profile has an avatar
+// that could not be loaded.
+func loadAvatar(ctx context.Context, store storage.Storage, profile *models.Profile) (img image.Image, complete bool) {
+	avatar := profile.Avatar
+	if avatar == "" || avatar == "default-avatar.png" {
+		return nil, true
+	}
+	key, ok := storage.KeyFromRef(store, avatar)
+	if !ok {
+		return nil, true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, avatarTimeout)
+	defer cancel()
+
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, errors.Is(err, storage.ErrNotFound)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxAvatarBytes))
+	if err != nil {
+		return nil, false
+	}
+
+	// Not an image we can draw, or too large to decode: initials are final
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, true
+	}
+	img, _, err = image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, true
+	}
+	return img, true
+}
+
+// Helper: Short profile URL shown on cards, e.g. "linkmy.io/john"
+func (h *ProfileHandler) profileDisplayURL(profile *models.Profile) string {
+	host := h.cfg.BaseURL
+	if u, err := url.Parse(h.cfg.BaseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return strings.TrimPrefix(host, "www.") + "/" + profile.Slug
+}
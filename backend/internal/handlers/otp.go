@@ -3,8 +3,6 @@ package handlers
 import (
 	"crypto/rand"
 	"fmt"
-	"net/smtp"
-	"os"
 	"sync"
 	"time"
 )
@@ -65,20 +63,6 @@ func DeleteOTP(email string) {
 
 // SendOTPEmail sends OTP via Gmail SMTP
 func SendOTPEmail(toEmail, otp string) error {
-	smtpHost := os.Getenv("MAIL_HOST")
-	smtpPort := os.Getenv("MAIL_PORT")
-	smtpUser := os.Getenv("MAIL_USERNAME")
-	smtpPass := os.Getenv("MAIL_PASSWORD")
-	mailFrom := os.Getenv("MAIL_FROM")
-	
-	if smtpHost == "" || smtpUser == "" || smtpPass == "" {
-		return fmt.Errorf("SMTP not configured: host=%s, user=%s", smtpHost, smtpUser)
-	}
-	
-	if mailFrom == "" {
-		mailFrom = smtpUser
-	}
-	
 	subject := "Kode Verifikasi LinkMy"
 	body := fmt.Sprintf(`
 Hai!
@@ -95,11 +79,5 @@ Jika kamu tidak meminta kode ini, abaikan email ini.
 Tim LinkMy
 `, otp)
 	
-	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
-		mailFrom, toEmail, subject, body)
-	
-	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
-	
-	addr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
-	return smtp.SendMail(addr, auth, mailFrom, []string{toEmail}, []byte(msg))
+	return sendMail(toEmail, subject, body)
 }
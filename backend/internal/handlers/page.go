@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RenderProfilePage serves the server-rendered public profile page (public
+// endpoint). The page works without JavaScript and carries the meta tags
+// used by search engines and link previews.
+func (h *ProfileHandler) RenderProfilePage(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+	if unescaped, err := url.PathUnescape(slug); err == nil {
+		slug = unescaped
+	}
+
+	ctx := context.Background()
+
+	profile, err := h.profileRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return h.redirectRenamedPage(c, slug)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	// Unlisted and protected profiles must never be indexed
+	if !profile.IsIndexable() {
+		c.Set("X-Robots-Tag", "noindex, nofollow")
+	}
+
+	if profile.Visibility == models.VisibilityPassword {
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		if !isProfileUnlocked(c, h.cfg.JWTSecret, profile) {
+			return h.renderPasswordPage(c, profile, fiber.StatusUnauthorized, "")
+		}
+	}
+
+	data, err := h.loadPublicProfile(ctx, profile)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	page, err := h.renderer.Profile(data)
+	if err != nil {
+		return err
+	}
+
+	h.recordView(ctx, c, profile)
+
+	// Revalidate cheaply: unchanged pages are answered with 304
+	sum := sha256.Sum256(page)
+	etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+	c.Set(fiber.HeaderETag, etag)
+	if profile.Visibility != models.VisibilityPassword {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=60")
+	}
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return sendPage(c, fiber.StatusOK, page)
+}
+
+// UnlockProfilePage handles the password form of a protected profile page
+// and sends the visitor back to the page once unlocked
+func (h *ProfileHandler) UnlockProfilePage(c *fiber.Ctx) error {
+	ctx := context.Background()
+
+	profile, err := h.profileRepo.GetBySlug(ctx, unlockSlug(c))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return h.renderNotFoundPage(c)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	location := "/" + url.PathEscape(profile.Slug)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	// Nothing to unlock
+	if profile.Visibility != models.VisibilityPassword || profile.PasswordHash == nil {
+		return c.Redirect(location, fiber.StatusSeeOther)
+	}
+
+	password := c.FormValue("password")
+	if bcrypt.CompareHashAndPassword([]byte(*profile.PasswordHash), []byte(password)) != nil {
+		return h.renderPasswordPage(c, profile, fiber.StatusUnauthorized, "Incorrect password")
+	}
+
+	if _, err := h.issueUnlockCookie(c, profile); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to generate token")
+	}
+	return c.Redirect(location, fiber.StatusSeeOther)
+}
+
+// Helper: Redirect the page of a renamed profile's old slug, or show the
+// not found page
+func (h *ProfileHandler) redirectRenamedPage(c *fiber.Ctx, slug string) error {
+	location, _, err := h.renamedProfileLocation(c, slug)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return h.renderNotFoundPage(c)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	// Cached briefly, see redirectRenamedProfile
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Redirect(location, fiber.StatusMovedPermanently)
+}
+
+// Helper: Render the unlock form using the profile's theme
+func (h *ProfileHandler) renderPasswordPage(c *fiber.Ctx, profile *models.Profile, status int, message string) error {
+	profile, theme, err := loadPublicAppearance(context.Background(), h.pubRepo, h.themeRepo, profile)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	page, err := h.renderer.Password(profile, theme, message)
+	if err != nil {
+		return err
+	}
+	return sendPage(c, status, page)
+}
+
+// Helper: Render the not found page
+func (h *ProfileHandler) renderNotFoundPage(c *fiber.Ctx) error {
+	page, err := h.renderer.NotFound()
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=60")
+	return sendPage(c, fiber.StatusNotFound, page)
+}
+
+// sendPage writes a rendered HTML page
+func sendPage(c *fiber.Ctx, status int, page []byte) error {
+	c.Type("html", "utf-8")
+	return c.Status(status).Send(page)
+}
@@ -2,13 +2,39 @@ package handlers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/FahmiYoshikage/linkmy-v2/internal/config"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/ogimage"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/render"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/storage"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/themecss"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// How long an unlocked password-protected profile stays accessible
+const profileUnlockTTL = 24 * time.Hour
+
+// Wrong passwords allowed per IP and per profile in each window before
+// further unlock attempts are refused
+const (
+	unlockAttemptsPerIP      = 10
+	unlockAttemptsPerProfile = 50
+	unlockAttemptWindow      = 15 * time.Minute
 )
 
 type ProfileHandler struct {
@@ -17,15 +43,29 @@ type ProfileHandler struct {
 	categoryRepo *repository.CategoryRepository
 	themeRepo    *repository.ThemeRepository
 	userRepo     *repository.UserRepository
+	pubRepo      *repository.PublicationRepository
+	auditRepo    *repository.AuditRepository
+	renderer     *render.Renderer
+	ogCache      *ogimage.Cache
+	storage      storage.Storage
+	assetGC      *storage.Collector
+	cfg          *config.Config
 }
 
-func NewProfileHandler(db *pgxpool.Pool) *ProfileHandler {
+func NewProfileHandler(db *pgxpool.Pool, cfg *config.Config, store storage.Storage, assetGC *storage.Collector) *ProfileHandler {
 	return &ProfileHandler{
 		profileRepo:  repository.NewProfileRepository(db),
 		linkRepo:     repository.NewLinkRepository(db),
 		categoryRepo: repository.NewCategoryRepository(db),
 		themeRepo:    repository.NewThemeRepository(db),
 		userRepo:     repository.NewUserRepository(db),
+		pubRepo:      repository.NewPublicationRepository(db),
+		auditRepo:    repository.NewAuditRepository(db),
+		renderer:     render.New(cfg.BaseURL, store),
+		ogCache:      ogimage.NewCache(ogCacheSize),
+		storage:      store,
+		assetGC:      assetGC,
+		cfg:          cfg,
 	}
 }
 
@@ -42,15 +82,126 @@ func (h *ProfileHandler) GetPublicProfile(c *fiber.Ctx) error {
 	profile, err := h.profileRepo.GetBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return NotFound(c, "Profile")
+			return h.redirectRenamedProfile(c, slug)
 		}
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
 	}
 
+	// Unlisted and protected profiles must never be indexed
+	if !profile.IsIndexable() {
+		c.Set("X-Robots-Tag", "noindex, nofollow")
+	}
+
+	if profile.Visibility == models.VisibilityPassword {
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		if !isProfileUnlocked(c, h.cfg.JWTSecret, profile) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "password_required",
+				"message": "This profile is password protected",
+				"data": fiber.Map{
+					"slug": profile.Slug,
+					"name": profile.Name,
+				},
+			})
+		}
+	}
+
+	data, err := h.loadPublicProfile(ctx, profile)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	h.recordView(ctx, c, profile)
+
+	return SuccessResponse(c, data)
+}
+
+// Helper: Record a view of a public profile for analytics. The source comes
+// from the src query parameter (e.g. "qr"); crawlers are not counted.
+func (h *ProfileHandler) recordView(ctx context.Context, c *fiber.Ctx, profile *models.Profile) {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if isCrawler(userAgent) {
+		return
+	}
+
+	source := c.Query("src")
+	view := &models.ProfileView{
+		ProfileID: profile.ID,
+		Source:    trafficSource(&source),
+		UserAgent: &userAgent,
+	}
+	if country := visitorCountry(c); country != "" {
+		view.Country = &country
+	}
+	if referrer := c.Get(fiber.HeaderReferer); referrer != "" {
+		view.Referrer = &referrer
+	}
+	h.profileRepo.RecordView(ctx, view)
+}
+
+// isCrawler reports whether a user agent belongs to a search engine or link
+// preview bot
+func isCrawler(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, marker := range []string{"bot", "crawler", "spider", "facebookexternalhit", "embedly", "preview", "slurp"} {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper: Load everything visitors see on a profile: its live publication
+// or, for profiles never published, its current content
+func (h *ProfileHandler) loadPublicProfile(ctx context.Context, profile *models.Profile) (*models.PublicProfile, error) {
+	pub, err := h.pubRepo.GetLive(ctx, profile.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return h.loadDraftProfile(ctx, profile)
+		}
+		return nil, err
+	}
+
+	// Screening and deletion apply at once: links held or deleted since
+	// publishing are left out
+	current, err := h.linkRepo.GetByProfileID(ctx, profile.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	servable := make(map[int]bool)
+	for _, l := range current {
+		if !l.UnderReview() {
+			servable[l.ID] = true
+		}
+	}
+	links := []models.Link{}
+	for _, l := range pub.Content.Links {
+		if servable[l.ID] && !l.UnderReview() {
+			links = append(links, l)
+		}
+	}
+
+	theme := pub.Content.Theme
+	return &models.PublicProfile{
+		Profile:    pub.Content.Apply(*profile),
+		Theme:      theme,
+		ThemeCSS:   themecss.Compile(&theme.ThemeSettings),
+		Categories: pub.Content.Categories,
+		Links:      links,
+		IsVerified: h.isOwnerVerified(ctx, profile),
+	}, nil
+}
+
+// Helper: Load the current content of a profile, as published next: theme,
+// categories, active links and the owner's verification status
+func (h *ProfileHandler) loadDraftProfile(ctx context.Context, profile *models.Profile) (*models.PublicProfile, error) {
 	// Get theme
 	theme, err := h.themeRepo.GetByProfileID(ctx, profile.ID)
-	if err != nil && !errors.Is(err, repository.ErrNotFound) {
-		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		theme = &models.Theme{ProfileID: profile.ID}
 	}
 
 	// Get categories
@@ -65,19 +216,166 @@ func (h *ProfileHandler) GetPublicProfile(c *fiber.Ctx) error {
 		links = []models.Link{}
 	}
 
-	// Get user verification status
-	user, _ := h.userRepo.GetByID(ctx, profile.UserID)
-	isVerified := false
-	if user != nil {
-		isVerified = user.IsVerified
-	}
-
-	return SuccessResponse(c, models.PublicProfile{
+	return &models.PublicProfile{
 		Profile:    *profile,
 		Theme:      *theme,
+		ThemeCSS:   themecss.Compile(&theme.ThemeSettings),
 		Categories: categories,
 		Links:      links,
-		IsVerified: isVerified,
+		IsVerified: h.isOwnerVerified(ctx, profile),
+	}, nil
+}
+
+// Helper: Load the profile and theme visitors see, for pages that show no
+// links
+func loadPublicAppearance(ctx context.Context, pubRepo *repository.PublicationRepository, themeRepo *repository.ThemeRepository, profile *models.Profile) (*models.Profile, *models.Theme, error) {
+	pub, err := pubRepo.GetLive(ctx, profile.ID)
+	if err == nil {
+		published := pub.Content.Apply(*profile)
+		return &published, &pub.Content.Theme, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	theme, err := themeRepo.GetByProfileID(ctx, profile.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, err
+	}
+	return profile, theme, nil
+}
+
+// Helper: Get the verification status of a profile's owner
+func (h *ProfileHandler) isOwnerVerified(ctx context.Context, profile *models.Profile) bool {
+	user, _ := h.userRepo.GetByID(ctx, profile.UserID)
+	return user != nil && user.IsVerified
+}
+
+// Helper: Send visitors of a renamed profile's old slug to its current slug.
+// The redirect is permanent but only cached briefly, since the old slug can
+// be claimed by someone else once its quarantine ends.
+func (h *ProfileHandler) redirectRenamedProfile(c *fiber.Ctx, slug string) error {
+	location, current, err := h.renamedProfileLocation(c, slug)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Profile")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	c.Set(fiber.HeaderLocation, location)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Status(fiber.StatusMovedPermanently).JSON(fiber.Map{
+		"error":   "profile_moved",
+		"message": "This profile has moved",
+		"data": fiber.Map{
+			"slug":     current,
+			"location": location,
+		},
+	})
+}
+
+// Helper: Build the URL of the current route for the profile that used to
+// own slug, keeping the query string. Returns ErrNotFound if slug was never
+// renamed.
+func (h *ProfileHandler) renamedProfileLocation(c *fiber.Ctx, slug string) (location, current string, err error) {
+	current, err = h.profileRepo.GetRedirectSlug(context.Background(), slug)
+	if err != nil {
+		return "", "", err
+	}
+
+	location = strings.Replace(c.Route().Path, ":slug", url.PathEscape(current), 1)
+	if query := c.Context().QueryArgs().String(); query != "" {
+		location += "?" + query
+	}
+	return location, current, nil
+}
+
+// UnlockRateLimit returns the limiters of wrong passwords on protected
+// profiles, per IP and per profile, so that passwords cannot be guessed
+// online. Successful unlocks do not count. page selects the server-rendered
+// password form as the response to refused attempts.
+func (h *ProfileHandler) UnlockRateLimit(page bool) (perIP, perProfile fiber.Handler) {
+	limitReached := func(c *fiber.Ctx) error {
+		if page {
+			profile, err := h.profileRepo.GetBySlug(context.Background(), unlockSlug(c))
+			if err != nil {
+				return h.renderNotFoundPage(c)
+			}
+			return h.renderPasswordPage(c, profile, fiber.StatusTooManyRequests, "Too many attempts, please try again later")
+		}
+		return ErrorResponse(c, fiber.StatusTooManyRequests, "Too many attempts, please try again later")
+	}
+
+	perIP = limiter.New(limiter.Config{
+		Max:                    unlockAttemptsPerIP,
+		Expiration:             unlockAttemptWindow,
+		SkipSuccessfulRequests: true,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "unlock-ip:" + c.IP()
+		},
+		LimitReached: limitReached,
+	})
+	perProfile = limiter.New(limiter.Config{
+		Max:                    unlockAttemptsPerProfile,
+		Expiration:             unlockAttemptWindow,
+		SkipSuccessfulRequests: true,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "unlock-profile:" + unlockSlug(c)
+		},
+		LimitReached: limitReached,
+	})
+	return perIP, perProfile
+}
+
+// unlockSlug returns the slug of the profile an unlock attempt is made on
+func unlockSlug(c *fiber.Ctx) string {
+	slug := c.Params("slug")
+	if unescaped, err := url.PathUnescape(slug); err == nil {
+		slug = unescaped
+	}
+	return slug
+}
+
+// UnlockProfile verifies the password of a protected profile and issues an unlock token
+func (h *ProfileHandler) UnlockProfile(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	var req models.UnlockProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	ctx := context.Background()
+
+	profile, err := h.profileRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Profile")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	// Nothing to unlock
+	if profile.Visibility != models.VisibilityPassword || profile.PasswordHash == nil {
+		return SuccessResponse(c, fiber.Map{"message": "Profile is not password protected"})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(*profile.PasswordHash), []byte(req.Password)); err != nil {
+		return ErrorResponse(c, fiber.StatusUnauthorized, "Incorrect password")
+	}
+
+	token, err := h.issueUnlockCookie(c, profile)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	return SuccessResponse(c, fiber.Map{
+		"token":      token,
+		"expires_in": int64(profileUnlockTTL.Seconds()),
 	})
 }
 
@@ -132,25 +430,21 @@ func (h *ProfileHandler) CreateProfile(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
-
-	if len(req.Slug) < 3 {
-		return ValidationError(c, "Slug must be at least 3 characters")
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
 	}
 
 	ctx := context.Background()
 
-	// Check if slug exists
-	exists, err := h.profileRepo.ExistsSlug(ctx, req.Slug)
+	// Check the slug policy and that the slug (or a lookalike) is free
+	slug, err := claimSlug(ctx, h.profileRepo, req.Slug, 0, userID)
 	if err != nil {
-		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
-	}
-	if exists {
-		return ErrorResponse(c, fiber.StatusConflict, "Slug already taken")
+		return slugErrorResponse(c, err)
 	}
 
 	profile := &models.Profile{
 		UserID:   userID,
-		Slug:     req.Slug,
+		Slug:     slug,
 		Name:     req.Name,
 		Title:    req.Title,
 		Bio:      req.Bio,
@@ -165,6 +459,14 @@ func (h *ProfileHandler) CreateProfile(c *fiber.Ctx) error {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create profile")
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &userID,
+		Action:       models.AuditProfileCreate,
+		TargetType:   models.AuditTargetProfile,
+		TargetID:     profile.ID,
+		Changes:      auditChanges(nil, profile),
+	})
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
 		"data":    profile,
@@ -191,15 +493,23 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 	if err != nil {
 		return NotFound(c, "Profile")
 	}
+	before := *profile
 
 	var req models.UpdateProfileRequest
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	// Update fields if provided
 	if req.Slug != nil {
-		profile.Slug = *req.Slug
+		slug, err := claimSlug(ctx, h.profileRepo, *req.Slug, profileID, userID)
+		if err != nil {
+			return slugErrorResponse(c, err)
+		}
+		profile.Slug = slug
 	}
 	if req.Name != nil {
 		profile.Name = *req.Name
@@ -216,6 +526,25 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 	if req.IsActive != nil {
 		profile.IsActive = *req.IsActive
 	}
+	if req.Visibility != nil {
+		profile.Visibility = *req.Visibility
+	}
+	if req.Password != nil {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to hash password")
+		}
+		hash := string(hashed)
+		profile.PasswordHash = &hash
+	}
+
+	// A password only makes sense for protected profiles
+	if profile.Visibility == models.VisibilityPassword && profile.PasswordHash == nil {
+		return InvalidField(c, "password", "required_if", "password is required for password-protected profiles")
+	}
+	if profile.Visibility != models.VisibilityPassword {
+		profile.PasswordHash = nil
+	}
 
 	if err := h.profileRepo.Update(ctx, profile); err != nil {
 		if errors.Is(err, repository.ErrDuplicate) {
@@ -224,6 +553,20 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update profile")
 	}
 
+	// Password hashes are not part of the JSON form, so only record that the
+	// password changed
+	changes := auditChanges(&before, profile)
+	if req.Password != nil {
+		changes["password"] = models.AuditChange{From: auditRedacted, To: auditRedacted}
+	}
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &userID,
+		Action:       models.AuditProfileUpdate,
+		TargetType:   models.AuditTargetProfile,
+		TargetID:     profileID,
+		Changes:      changes,
+	})
+
 	return SuccessResponse(c, profile)
 }
 
@@ -243,9 +586,105 @@ func (h *ProfileHandler) DeleteProfile(c *fiber.Ctx) error {
 		return Forbidden(c)
 	}
 
+	profile, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		return NotFound(c, "Profile")
+	}
+
 	if err := h.profileRepo.Delete(ctx, profileID); err != nil {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete profile")
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &userID,
+		Action:       models.AuditProfileDelete,
+		TargetType:   models.AuditTargetProfile,
+		TargetID:     profileID,
+		Changes:      auditChanges(profile, nil),
+	})
+
+	// Remove the profile's uploaded images
+	h.assetGC.Trigger()
+
 	return SuccessResponse(c, fiber.Map{"message": "Profile deleted"})
 }
+
+// Helper: Generate a token granting access to a password-protected profile
+func (h *ProfileHandler) generateUnlockToken(profile *models.Profile) (string, error) {
+	claims := jwt.MapClaims{
+		"purpose":    "profile_unlock",
+		"profile_id": profile.ID,
+		"pwv":        passwordVersion(profile),
+		"exp":        time.Now().Add(profileUnlockTTL).Unix(),
+		"iat":        time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(h.cfg.JWTSecret))
+}
+
+// Helper: Generate an unlock token and set it as the profile's unlock cookie
+func (h *ProfileHandler) issueUnlockCookie(c *fiber.Ctx, profile *models.Profile) (string, error) {
+	token, err := h.generateUnlockToken(profile)
+	if err != nil {
+		return "", err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     unlockCookieName(profile.ID),
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(profileUnlockTTL),
+		HTTPOnly: true,
+		Secure:   h.cfg.IsProduction(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return token, nil
+}
+
+// Helper: Check the unlock cookie or X-Profile-Token header for a valid token
+func isProfileUnlocked(c *fiber.Ctx, secret string, profile *models.Profile) bool {
+	tokenString := c.Get("X-Profile-Token")
+	if tokenString == "" {
+		tokenString = c.Cookies(unlockCookieName(profile.ID))
+	}
+	if tokenString == "" {
+		return false
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fiber.ErrUnauthorized
+		}
+		return []byte(secret), nil
+	})
+	if err != nil || !token.Valid {
+		return false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "profile_unlock" {
+		return false
+	}
+	profileID, ok := claims["profile_id"].(float64)
+	if !ok || int(profileID) != profile.ID {
+		return false
+	}
+
+	// Changing the password invalidates previously issued tokens
+	return claims["pwv"] == passwordVersion(profile)
+}
+
+// unlockCookieName returns the per-profile unlock cookie name
+func unlockCookieName(profileID int) string {
+	return "lm_unlock_" + strconv.Itoa(profileID)
+}
+
+// passwordVersion fingerprints the current password hash without exposing it
+func passwordVersion(profile *models.Profile) string {
+	if profile.PasswordHash == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(*profile.PasswordHash))
+	return hex.EncodeToString(sum[:8])
+}
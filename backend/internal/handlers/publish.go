@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// How long a draft preview link works
+const previewTokenTTL = time.Hour
+
+// Publish makes the current content of a profile (name, title, bio, avatar,
+// theme, categories and active links) what visitors see. From the first
+// publish on, edits stay a draft until the next one.
+func (h *ProfileHandler) Publish(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	profile, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		return NotFound(c, "Profile")
+	}
+
+	content, err := h.draftContent(ctx, profile)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to load draft")
+	}
+
+	pub, err := h.pubRepo.Publish(ctx, profileID, content)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Profile")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to publish profile")
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &userID,
+		Action:       models.AuditProfilePublish,
+		TargetType:   models.AuditTargetProfile,
+		TargetID:     profileID,
+		Changes:      map[string]models.AuditChange{"publication_id": {To: pub.ID}},
+	})
+
+	return SuccessResponse(c, pub)
+}
+
+// RollbackPublication makes the previous publication of a profile live
+// again. The draft is left as it is.
+func (h *ProfileHandler) RollbackPublication(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	pub, err := h.pubRepo.Rollback(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Previous publication")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to roll back profile")
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		TargetUserID: &userID,
+		Action:       models.AuditProfileRollback,
+		TargetType:   models.AuditTargetProfile,
+		TargetID:     profileID,
+		Changes:      map[string]models.AuditChange{"publication_id": {To: pub.ID}},
+	})
+
+	return SuccessResponse(c, pub)
+}
+
+// GetPublications returns the publications of a profile, newest first
+func (h *ProfileHandler) GetPublications(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	pubs, err := h.pubRepo.ListByProfileID(ctx, profileID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch publications")
+	}
+
+	return SuccessResponse(c, pubs)
+}
+
+// CreatePreviewToken issues a short-lived link to the draft of a profile,
+// which works without logging in so it can be shared for review
+func (h *ProfileHandler) CreatePreviewToken(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	expiresAt := time.Now().Add(previewTokenTTL)
+	claims := jwt.MapClaims{
+		"purpose":    "profile_preview",
+		"profile_id": profileID,
+		"exp":        expiresAt.Unix(),
+		"iat":        time.Now().Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.cfg.JWTSecret))
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	return SuccessResponse(c, fiber.Map{
+		"token":      token,
+		"url":        strings.TrimRight(h.cfg.BaseURL, "/") + "/preview/" + token,
+		"expires_at": expiresAt,
+	})
+}
+
+// GetPreview returns the draft of a profile for a preview token (public
+// endpoint)
+func (h *ProfileHandler) GetPreview(c *fiber.Ctx) error {
+	profile, err := h.previewProfile(c.Params("token"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Preview")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	data, err := h.loadDraftProfile(context.Background(), profile)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	c.Set("X-Robots-Tag", "noindex, nofollow")
+	return SuccessResponse(c, data)
+}
+
+// RenderPreviewPage serves the server-rendered draft of a profile for a
+// preview token (public endpoint). Views are not counted.
+func (h *ProfileHandler) RenderPreviewPage(c *fiber.Ctx) error {
+	profile, err := h.previewProfile(c.Params("token"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return h.renderNotFoundPage(c)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+
+	data, err := h.loadDraftProfile(context.Background(), profile)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+	}
+	page, err := h.renderer.Profile(data)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	c.Set("X-Robots-Tag", "noindex, nofollow")
+	return sendPage(c, fiber.StatusOK, page)
+}
+
+// Helper: Load the profile a preview token was issued for. Invalid and
+// expired tokens are reported as ErrNotFound.
+func (h *ProfileHandler) previewProfile(tokenString string) (*models.Profile, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fiber.ErrUnauthorized
+		}
+		return []byte(h.cfg.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, repository.ErrNotFound
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "profile_preview" {
+		return nil, repository.ErrNotFound
+	}
+	profileID, ok := claims["profile_id"].(float64)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return h.profileRepo.GetByID(context.Background(), int(profileID))
+}
+
+// Helper: Snapshot the current content of a profile for publishing
+func (h *ProfileHandler) draftContent(ctx context.Context, profile *models.Profile) (*models.PublishedContent, error) {
+	theme, err := h.themeRepo.GetByProfileID(ctx, profile.ID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		theme = &models.Theme{ProfileID: profile.ID}
+	}
+
+	categories, err := h.categoryRepo.GetByProfileID(ctx, profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		categories = []models.Category{}
+	}
+
+	links, err := h.linkRepo.GetByProfileID(ctx, profile.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []models.Link{}
+	}
+
+	return &models.PublishedContent{
+		Name:       profile.Name,
+		Title:      profile.Title,
+		Bio:        profile.Bio,
+		Avatar:     profile.Avatar,
+		Theme:      *theme,
+		Categories: categories,
+		Links:      links,
+	}, nil
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/config"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/qr"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/storage"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type QRHandler struct {
+	profileRepo *repository.ProfileRepository
+	linkRepo    *repository.LinkRepository
+	themeRepo   *repository.ThemeRepository
+	pubRepo     *repository.PublicationRepository
+	storage     storage.Storage
+	cfg         *config.Config
+}
+
+func NewQRHandler(db *pgxpool.Pool, cfg *config.Config, store storage.Storage) *QRHandler {
+	return &QRHandler{
+		profileRepo: repository.NewProfileRepository(db),
+		linkRepo:    repository.NewLinkRepository(db),
+		themeRepo:   repository.NewThemeRepository(db),
+		pubRepo:     repository.NewPublicationRepository(db),
+		storage:     store,
+		cfg:         cfg,
+	}
+}
+
+// GetProfileQR returns a QR code of the profile page. Scans are tagged with
+// the qr source in analytics.
+func (h *QRHandler) GetProfileQR(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	var req models.QRCodeRequest
+	if err := c.QueryParser(&req); err != nil {
+		return QueryFailed(c, err)
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	profile, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		return NotFound(c, "Profile")
+	}
+
+	target := h.publicURL("/"+url.PathEscape(profile.Slug)) + "?src=" + models.SourceQR
+	return h.sendQR(ctx, c, &req, profile, target, "linkmy-"+profile.Slug)
+}
+
+// GetLinkQR returns a QR code that opens a single link through the tracked
+// redirect
+func (h *QRHandler) GetLinkQR(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid link ID")
+	}
+
+	var req models.QRCodeRequest
+	if err := c.QueryParser(&req); err != nil {
+		return QueryFailed(c, err)
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	ownerID, err := h.linkRepo.GetProfileOwner(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+	if ownerID != userID {
+		return Forbidden(c)
+	}
+
+	link, err := h.linkRepo.GetByID(ctx, linkID)
+	if err != nil {
+		return NotFound(c, "Link")
+	}
+	profile, err := h.profileRepo.GetByID(ctx, link.ProfileID)
+	if err != nil {
+		return NotFound(c, "Profile")
+	}
+
+	target := h.publicURL("/r/"+strconv.Itoa(link.ID)) + "?src=" + models.SourceQR
+	return h.sendQR(ctx, c, &req, profile, target, fmt.Sprintf("linkmy-%s-link-%d", profile.Slug, link.ID))
+}
+
+// Helper: Render the QR code of target in the profile's live theme, with its
+// live avatar as the logo, and send it
+func (h *QRHandler) sendQR(ctx context.Context, c *fiber.Ctx, req *models.QRCodeRequest, profile *models.Profile, target, filename string) error {
+	opts := qr.DefaultOptions()
+	if req.Size != 0 {
+		opts.Size = req.Size
+	}
+	if req.Margin != nil {
+		opts.Margin = *req.Margin
+	}
+	if req.Level != "" {
+		opts.Level = qr.Level(req.Level)
+	}
+
+	// Theme button color on white, unless it would not scan. The code leads
+	// to the live page, so it matches the published theme rather than a draft.
+	profile, theme, err := loadPublicAppearance(ctx, h.pubRepo, h.themeRepo, profile)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+	if theme != nil {
+		if fg, ok := qr.ParseHex(theme.ButtonColor); ok && qr.Scannable(fg, opts.Background) {
+			opts.Foreground = fg
+		}
+	}
+
+	// Explicit colors are used as given, but must still scan
+	if fg, ok := qr.ParseHex(req.Foreground); ok {
+		opts.Foreground = fg
+	}
+	if bg, ok := qr.ParseHex(req.Background); ok {
+		opts.Background = bg
+	}
+	if (req.Foreground != "" || req.Background != "") && !qr.Scannable(opts.Foreground, opts.Background) {
+		return ValidationFailed(c, []models.FieldError{{
+			Field:   "fg",
+			Rule:    "contrast",
+			Message: "Foreground must be clearly darker than the background for the code to scan",
+		}})
+	}
+
+	if req.Logo {
+		opts.Logo, _ = loadAvatar(ctx, h.storage, profile)
+	}
+
+	var body []byte
+	if req.Format == "svg" {
+		body, err = qr.SVG(target, opts)
+		c.Type("svg")
+		filename += ".svg"
+	} else {
+		body, err = qr.PNG(target, opts)
+		c.Type("png")
+		filename += ".png"
+	}
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate QR code")
+	}
+
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="`+filename+`"`)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return c.Send(body)
+}
+
+// Helper: Absolute URL of a public path
+func (h *QRHandler) publicURL(path string) string {
+	return strings.TrimRight(h.cfg.BaseURL, "/") + path
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/captcha"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/pagination"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Header carrying a solved captcha, which lifts the report rate limit
+const captchaHeader = "X-Captcha-Token"
+
+type ReportHandler struct {
+	reportRepo *repository.ReportRepository
+	captcha    *captcha.Verifier
+}
+
+func NewReportHandler(db *pgxpool.Pool, verifier *captcha.Verifier) *ReportHandler {
+	return &ReportHandler{
+		reportRepo: repository.NewReportRepository(db),
+		captcha:    verifier,
+	}
+}
+
+// RateLimit limits reports to max per hour and IP. With captchas configured,
+// requests carrying a captcha token skip the limit and have the token checked
+// by CreateReport instead; limited clients are told to solve one.
+func (h *ReportHandler) RateLimit(max int) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: time.Hour,
+		Next: func(c *fiber.Ctx) bool {
+			return h.captcha.Enabled() && c.Get(captchaHeader) != ""
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":            true,
+				"message":          "Too many reports, please try again later",
+				"captcha_required": h.captcha.Enabled(),
+			})
+		},
+	})
+}
+
+// CreateReport files an abuse report about a profile or link. Anyone can
+// report; an email address is only needed to hear the outcome.
+func (h *ReportHandler) CreateReport(c *fiber.Ctx) error {
+	var req models.CreateReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	// Bots fill in the honeypot field; they are told the report was filed
+	if req.Website != "" {
+		return reportReceived(c)
+	}
+
+	ctx := context.Background()
+
+	if token := c.Get(captchaHeader); token != "" && h.captcha.Enabled() {
+		ok, err := h.captcha.Verify(ctx, token, c.IP())
+		if err != nil {
+			log.Printf("captcha: verification failed: %v", err)
+			return ErrorResponse(c, fiber.StatusServiceUnavailable, "Captcha verification unavailable")
+		}
+		if !ok {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":   "validation_error",
+				"message": "Validation failed",
+				"fields": []models.FieldError{
+					{Field: "captcha", Rule: "captcha", Message: "captcha is invalid"},
+				},
+				"captcha_required": true,
+			})
+		}
+	}
+
+	ip := c.IP()
+	report := &models.Report{
+		TargetType:    req.TargetType,
+		TargetID:      req.TargetID,
+		Reason:        req.Reason,
+		Details:       req.Details,
+		ReporterEmail: req.Email,
+		ReporterIP:    &ip,
+	}
+	if err := h.reportRepo.Create(ctx, report); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			if req.TargetType == models.ReportTargetLink {
+				return NotFound(c, "Link")
+			}
+			return NotFound(c, "Profile")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to file report")
+	}
+
+	return reportReceived(c)
+}
+
+// ListReports returns the report queue for admin, newest first, a page at a
+// time. Filters: status (open by default, "all" for every status),
+// target_type, target_id and reason.
+func (h *AdminHandler) ListReports(c *fiber.Ctx) error {
+	page, err := pagination.FromQuery(c, repository.ReportSorts, "created_at")
+	if err != nil {
+		return paginationError(c, err)
+	}
+
+	filter := repository.ReportFilter{
+		Status:     c.Query("status", models.ReportOpen),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.QueryInt("target_id"),
+		Reason:     c.Query("reason"),
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	}
+
+	reports, err := h.reportRepo.List(context.Background(), filter, page)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	return SuccessResponse(c, reports)
+}
+
+// GetReport returns a report
+func (h *AdminHandler) GetReport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid report ID")
+	}
+
+	report, err := h.reportRepo.GetByID(context.Background(), int64(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Report")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+
+	return SuccessResponse(c, report)
+}
+
+// ResolveReport closes an open report. Resolving it can act on the target
+// the same way the other admin endpoints do, which takes their permission:
+// hide the profile, disable (block) the reported link or ban the owner.
+// The reporter is emailed the outcome if they left an address.
+func (h *AdminHandler) ResolveReport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid report ID")
+	}
+
+	var req models.AdminResolveReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+	if req.Action == "" {
+		req.Action = models.ReportActionNone
+	}
+	if req.Status == models.ReportDismissed && req.Action != models.ReportActionNone {
+		return InvalidField(c, "action", "oneof", "action must be none for dismissed reports")
+	}
+
+	ctx := context.Background()
+
+	report, err := h.reportRepo.GetByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Report")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+	if report.Status != models.ReportOpen {
+		return ErrorResponse(c, fiber.StatusConflict, "Report already closed")
+	}
+
+	// Claim the report first, so that of moderators resolving it at the same
+	// time only one takes the action
+	err = h.reportRepo.Close(ctx, report.ID, req.Status, req.Action, req.Note, middleware.GetUserID(c))
+	if err != nil {
+		if errors.Is(err, repository.ErrReportClosed) {
+			return ErrorResponse(c, fiber.StatusConflict, "Report already closed")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update report")
+	}
+
+	if err := h.applyReportAction(c, report, req.Action); err != nil {
+		if err := h.reportRepo.Reopen(ctx, report.ID); err != nil {
+			log.Printf("reports: failed to reopen report %d: %v", report.ID, err)
+		}
+		return actionError(c, err)
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditEvent{
+		Action:     models.AuditReportResolve,
+		TargetType: models.AuditTargetReport,
+		TargetID:   id,
+		Changes: map[string]models.AuditChange{
+			"status": {From: report.Status, To: req.Status},
+			"action": {From: report.Action, To: req.Action},
+		},
+	})
+
+	if report, err = h.reportRepo.GetByID(ctx, report.ID); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+	if report.ReporterEmail != nil {
+		go notifyReporter(*report)
+	}
+
+	return SuccessResponse(c, report)
+}
+
+// Helper: Take the action resolving a report, with the permission the same
+// change takes elsewhere
+func (h *AdminHandler) applyReportAction(c *fiber.Ctx, report *models.Report, action string) error {
+	permission := map[string]string{
+		models.ReportActionHideProfile: models.PermProfilesModerate,
+		models.ReportActionDisableLink: models.PermLinksReview,
+		models.ReportActionBanUser:     models.PermUsersManage,
+	}[action]
+	if permission == "" {
+		return nil
+	}
+	if !middleware.HasPermission(c, permission) {
+		return fiber.NewError(fiber.StatusForbidden, "Permission required: "+permission)
+	}
+	if report.ProfileID == nil {
+		return fiber.NewError(fiber.StatusNotFound, "Profile not found")
+	}
+
+	switch action {
+	case models.ReportActionHideProfile:
+		return h.setProfileActive(c, *report.ProfileID, false)
+
+	case models.ReportActionDisableLink:
+		if report.TargetType != models.ReportTargetLink {
+			return &fieldError{Field: "action", Rule: "target_type", Message: "action disable_link needs a link report"}
+		}
+		_, err := h.reviewLink(c, report.TargetID, "block")
+		return err
+
+	case models.ReportActionBanUser:
+		var ownerID int
+		err := h.db.QueryRow(context.Background(), "SELECT user_id FROM profiles WHERE id = $1", *report.ProfileID).Scan(&ownerID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "Profile not found")
+		}
+		active := false
+		_, err = h.updateUser(c, ownerID, models.AdminUpdateUserRequest{IsActive: &active})
+		return err
+	}
+	return nil
+}
+
+// Helper: Acknowledge a report without telling whether it was stored
+func reportReceived(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"message": "Report received"},
+	})
+}
+
+// notifyReporter emails the outcome of a report to whoever filed it.
+// Failures are logged; the report is closed either way.
+func notifyReporter(report models.Report) {
+	target := "profil"
+	if report.TargetType == models.ReportTargetLink {
+		target = "link"
+	}
+
+	outcome := "Kami sudah meninjaunya dan tidak menemukan pelanggaran, jadi tidak ada tindakan yang diambil."
+	if report.Status == models.ReportResolved {
+		switch report.Action {
+		case models.ReportActionHideProfile:
+			outcome = "Kami sudah meninjaunya dan menyembunyikan profil tersebut."
+		case models.ReportActionDisableLink:
+			outcome = "Kami sudah meninjaunya dan menonaktifkan link tersebut."
+		case models.ReportActionBanUser:
+			outcome = "Kami sudah meninjaunya dan menonaktifkan akun pemiliknya."
+		default:
+			outcome = "Kami sudah meninjaunya dan menindaklanjutinya."
+		}
+	}
+
+	subject := "Laporan LinkMy kamu sudah ditinjau"
+	body := fmt.Sprintf(`
+Hai!
+
+Terima kasih sudah melaporkan sebuah %s di LinkMy (laporan #%d).
+
+%s
+
+---
+Tim LinkMy
+`, target, report.ID, outcome)
+
+	if err := sendMail(*report.ReporterEmail, subject, body); err != nil {
+		log.Printf("reports: failed to notify reporter of report %d: %v", report.ID, err)
+	}
+}
@@ -1,6 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/linkurl"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/screening"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -44,6 +51,56 @@ func ValidationError(c *fiber.Ctx, message string) error {
 	})
 }
 
+// ValidationFailed reports every invalid field at once
+func ValidationFailed(c *fiber.Ctx, fields []models.FieldError) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":   "validation_error",
+		"message": "Validation failed",
+		"fields":  fields,
+	})
+}
+
+// InvalidField reports a single invalid field
+func InvalidField(c *fiber.Ctx, field, rule, message string) error {
+	return ValidationFailed(c, []models.FieldError{{Field: field, Rule: rule, Message: message}})
+}
+
+// QueryFailed reports query parameters that do not parse as their type, such
+// as size=big. QueryParser's error wraps a map of the parameters' names to
+// their conversion errors.
+func QueryFailed(c *fiber.Ctx, err error) error {
+	var fields []models.FieldError
+	if m := reflect.ValueOf(errors.Unwrap(err)); m.Kind() == reflect.Map && m.Type().Key().Kind() == reflect.String {
+		for _, key := range m.MapKeys() {
+			name := key.String()
+			fields = append(fields, models.FieldError{Field: name, Rule: "type", Message: name + " has an invalid value"})
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	}
+	if len(fields) == 0 {
+		fields = []models.FieldError{{Field: "", Rule: "type", Message: "Query parameters are invalid"}}
+	}
+	return ValidationFailed(c, fields)
+}
+
+// urlFieldError converts a linkurl error into a field error
+func urlFieldError(field string, err error) models.FieldError {
+	var urlErr *linkurl.Error
+	if !errors.As(err, &urlErr) {
+		urlErr = linkurl.ErrInvalid
+	}
+	return models.FieldError{Field: field, Rule: urlErr.Rule, Message: urlErr.Message}
+}
+
+// screeningFieldError reports a destination rejected by URL screening
+func screeningFieldError(field string, result screening.Result) models.FieldError {
+	rule := "suspicious_url"
+	if result.Status == models.ScreeningBlocked {
+		rule = "blocked_url"
+	}
+	return models.FieldError{Field: field, Rule: rule, Message: "URL failed safety screening: " + result.Reason()}
+}
+
 func NotFound(c *fiber.Ctx, resource string) error {
 	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 		"error":   "not_found",
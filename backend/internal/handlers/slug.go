@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/slugpolicy"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Number of alternatives offered for an unavailable slug
+const slugSuggestions = 5
+
+var (
+	errSlugTaken   = errors.New("slug already taken")
+	errSlugSimilar = errors.New("slug too similar to an existing profile")
+	errSlugHeld    = errors.New("slug recently released")
+)
+
+// CheckSlugAvailability reports whether a slug can be claimed and suggests
+// alternatives when it can't (public endpoint)
+func (h *ProfileHandler) CheckSlugAvailability(c *fiber.Ctx) error {
+	raw := c.Params("slug")
+	if unescaped, err := url.PathUnescape(raw); err == nil {
+		raw = unescaped
+	}
+
+	ctx := context.Background()
+
+	resp := models.SlugAvailabilityResponse{Slug: raw, Suggestions: []string{}}
+
+	slug, err := claimSlug(ctx, h.profileRepo, raw, 0, 0)
+	var policyErr *slugpolicy.Error
+	switch {
+	case err == nil:
+		resp.Slug, resp.Available = slug, true
+		return SuccessResponse(c, resp)
+	case errors.As(err, &policyErr):
+		resp.Rule, resp.Message = policyErr.Rule, policyErr.Message
+	case errors.Is(err, errSlugTaken):
+		resp.Slug = strings.ToLower(strings.TrimSpace(raw))
+		resp.Rule, resp.Message = "taken", "Slug already taken"
+	case errors.Is(err, errSlugSimilar):
+		resp.Slug = strings.ToLower(strings.TrimSpace(raw))
+		resp.Rule, resp.Message = "similar", "Slug is too similar to an existing profile"
+	case errors.Is(err, errSlugHeld):
+		resp.Slug = strings.ToLower(strings.TrimSpace(raw))
+		resp.Rule, resp.Message = "quarantined", "Slug was recently released and is not available yet"
+	default:
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+	}
+	resp.Suggestions = suggestSlugs(ctx, h.profileRepo, raw)
+
+	return SuccessResponse(c, resp)
+}
+
+// Helper: Apply the slug policy to raw and check that the slug is free for a
+// profile of userID. profileID is the profile being renamed, or 0.
+func claimSlug(ctx context.Context, profileRepo *repository.ProfileRepository, raw string, profileID, userID int) (string, error) {
+	slug, err := slugpolicy.Normalize(raw)
+	if err != nil {
+		return "", err
+	}
+
+	conflict, err := profileRepo.CheckSlug(ctx, slug, slugpolicy.Skeleton(slug), profileID, userID)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case conflict.Taken:
+		return "", errSlugTaken
+	case conflict.Similar:
+		return "", errSlugSimilar
+	case conflict.Quarantined:
+		return "", errSlugHeld
+	}
+	return slug, nil
+}
+
+// Helper: Report a claimSlug failure
+func slugErrorResponse(c *fiber.Ctx, err error) error {
+	var policyErr *slugpolicy.Error
+	switch {
+	case errors.As(err, &policyErr):
+		return ValidationFailed(c, []models.FieldError{{Field: "slug", Rule: policyErr.Rule, Message: policyErr.Message}})
+	case errors.Is(err, errSlugTaken):
+		return ErrorResponse(c, fiber.StatusConflict, "Slug already taken")
+	case errors.Is(err, errSlugSimilar):
+		return ErrorResponse(c, fiber.StatusConflict, "Slug is too similar to an existing profile")
+	case errors.Is(err, errSlugHeld):
+		return ErrorResponse(c, fiber.StatusConflict, "Slug was recently released and is not available yet")
+	}
+	return ErrorResponse(c, fiber.StatusInternalServerError, "Database error")
+}
+
+// Helper: Free alternatives to raw
+func suggestSlugs(ctx context.Context, profileRepo *repository.ProfileRepository, raw string) []string {
+	return slugpolicy.Suggest(raw, slugSuggestions, func(candidates []string) map[string]bool {
+		skeletons := make([]string, len(candidates))
+		for i, slug := range candidates {
+			skeletons[i] = slugpolicy.Skeleton(slug)
+		}
+		unavailable, err := profileRepo.UnavailableSlugs(ctx, candidates, skeletons)
+		if err != nil {
+			// Suggest nothing rather than something that may be taken
+			unavailable = make(map[string]bool, len(candidates))
+			for _, slug := range candidates {
+				unavailable[slug] = true
+			}
+		}
+		return unavailable
+	})
+}
+
+// Helper: Pick the slug for a profile created at registration: the username
+// when it is allowed and free, otherwise the first suggestion. Returns "" if
+// nothing suitable is found.
+func registrationSlug(ctx context.Context, profileRepo *repository.ProfileRepository, username string) string {
+	if slug, err := claimSlug(ctx, profileRepo, username, 0, 0); err == nil {
+		return slug
+	}
+	if suggestions := suggestSlugs(ctx, profileRepo, username); len(suggestions) > 0 {
+		return suggestions[0]
+	}
+	return ""
+}
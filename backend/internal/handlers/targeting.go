@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers set by CDNs / reverse proxies carrying the visitor's country
+var countryHeaders = []string{"CF-IPCountry", "X-Country-Code", "X-Vercel-IP-Country", "X-AppEngine-Country"}
+
+var (
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	languagePattern    = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+	knownOS            = map[string]bool{"ios": true, "android": true, "windows": true, "macos": true, "linux": true}
+)
+
+// visitorInfo is what targeting rules are evaluated against
+type visitorInfo struct {
+	Country   string   // ISO 3166-1 alpha-2, empty if unknown
+	OS        string   // ios, android, windows, macos, linux or empty
+	Languages []string // Accept-Language tags, most preferred first
+}
+
+// newVisitorInfo extracts targeting data from the click request
+func newVisitorInfo(c *fiber.Ctx) visitorInfo {
+	return visitorInfo{
+		Country:   visitorCountry(c),
+		OS:        detectOS(c.Get(fiber.HeaderUserAgent)),
+		Languages: parseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)),
+	}
+}
+
+// visitorCountry reads the country code provided by the edge proxy
+func visitorCountry(c *fiber.Ctx) string {
+	for _, header := range countryHeaders {
+		code := strings.ToUpper(strings.TrimSpace(c.Get(header)))
+		// Cloudflare uses XX for unknown and T1 for Tor
+		if countryCodePattern.MatchString(code) && code != "XX" {
+			return code
+		}
+	}
+	return ""
+}
+
+// detectOS maps a User-Agent to a coarse operating system family
+func detectOS(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	// iOS user agents also contain "like Mac OS X", so check them first
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return "ios"
+	case strings.Contains(ua, "android"):
+		return "android"
+	case strings.Contains(ua, "windows"):
+		return "windows"
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"):
+		return "macos"
+	case strings.Contains(ua, "linux"), strings.Contains(ua, "x11"):
+		return "linux"
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns language tags ordered by preference
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	languages := make([]string, len(tags))
+	for i, t := range tags {
+		languages[i] = t.tag
+	}
+	return languages
+}
+
+// matchRule returns the first rule the visitor satisfies, in rule order.
+// When that is a language rule, the language rule matching the visitor's
+// most preferred language wins instead, so "de, en;q=0.5" is sent to a
+// German rule even if an English rule comes first.
+func matchRule(rules []models.LinkRule, visitor visitorInfo) *models.LinkRule {
+	for i := range rules {
+		if !ruleMatches(&rules[i], visitor) {
+			continue
+		}
+		if rules[i].RuleType != models.RuleLanguage {
+			return &rules[i]
+		}
+		return matchLanguageRule(rules, visitor.Languages)
+	}
+	return nil
+}
+
+// matchLanguageRule returns the language rule for the most preferred
+// language that has one, the first in rule order among equals
+func matchLanguageRule(rules []models.LinkRule, languages []string) *models.LinkRule {
+	for _, lang := range languages {
+		for i := range rules {
+			if rules[i].RuleType == models.RuleLanguage && languageMatches(rules[i].MatchValues, lang) {
+				return &rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+func ruleMatches(rule *models.LinkRule, visitor visitorInfo) bool {
+	switch rule.RuleType {
+	case models.RuleCountry:
+		return visitor.Country != "" && containsValue(rule.MatchValues, visitor.Country)
+	case models.RuleOS:
+		return visitor.OS != "" && containsValue(rule.MatchValues, visitor.OS)
+	case models.RuleLanguage:
+		for _, lang := range visitor.Languages {
+			if languageMatches(rule.MatchValues, lang) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// languageMatches reports whether a rule for values covers the language:
+// a rule for "pt" matches "pt-br"; a rule for "pt-br" only matches "pt-br"
+func languageMatches(values []string, lang string) bool {
+	primary, _, _ := strings.Cut(lang, "-")
+	return containsValue(values, lang) || containsValue(values, primary)
+}
+
+func containsValue(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRuleValues canonicalises and validates the match values of a
+// rule, returning the invalid fields
+func normalizeRuleValues(ruleType string, values []string) ([]string, []models.FieldError) {
+	if len(values) == 0 {
+		return nil, []models.FieldError{{Field: "match_values", Rule: "required", Message: "match_values is required"}}
+	}
+
+	normalized := make([]string, 0, len(values))
+	var errs []models.FieldError
+	for i, raw := range values {
+		field := "match_values[" + strconv.Itoa(i) + "]"
+		v := strings.TrimSpace(raw)
+		switch ruleType {
+		case models.RuleCountry:
+			v = strings.ToUpper(v)
+			if !countryCodePattern.MatchString(v) {
+				errs = append(errs, models.FieldError{Field: field, Rule: "country", Message: "Country must be a two-letter ISO code: " + raw})
+				continue
+			}
+		case models.RuleOS:
+			v = strings.ToLower(v)
+			if !knownOS[v] {
+				errs = append(errs, models.FieldError{Field: field, Rule: "os", Message: "OS must be one of ios, android, windows, macos, linux: " + raw})
+				continue
+			}
+		case models.RuleLanguage:
+			v = strings.ToLower(strings.ReplaceAll(v, "_", "-"))
+			if !languagePattern.MatchString(v) {
+				errs = append(errs, models.FieldError{Field: field, Rule: "language", Message: "Invalid language tag: " + raw})
+				continue
+			}
+		default:
+			return nil, []models.FieldError{{Field: "rule_type", Rule: "oneof", Message: "rule_type must be one of: country, os, language"}}
+		}
+		if !containsValue(normalized, v) {
+			normalized = append(normalized, v)
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return normalized, nil
+}
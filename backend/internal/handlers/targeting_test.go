@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"de, en;q=0.5", []string{"de", "en"}},
+		{"en;q=0.5, de", []string{"de", "en"}},
+		{"pt-BR,pt;q=0.9,en;q=0.8,*;q=0.1", []string{"pt-br", "pt", "en"}},
+		{"fr;q=0, en", []string{"en"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := parseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	rules := []models.LinkRule{
+		{ID: 1, RuleType: models.RuleCountry, MatchValues: []string{"ID"}},
+		{ID: 2, RuleType: models.RuleLanguage, MatchValues: []string{"en"}},
+		{ID: 3, RuleType: models.RuleOS, MatchValues: []string{"ios"}},
+		{ID: 4, RuleType: models.RuleLanguage, MatchValues: []string{"de", "pt-br"}},
+		{ID: 5, RuleType: models.RuleLanguage, MatchValues: []string{"de"}},
+	}
+
+	tests := []struct {
+		name    string
+		visitor visitorInfo
+		want    int // rule ID, 0 for none
+	}{
+		{"country first", visitorInfo{Country: "ID", Languages: []string{"de"}}, 1},
+		{"preferred language", visitorInfo{Languages: []string{"de", "en"}}, 4},
+		{"rule order among equals", visitorInfo{Languages: []string{"de-at"}}, 4},
+		{"fallback language", visitorInfo{Languages: []string{"fr", "en"}}, 2},
+		{"regional tag", visitorInfo{Languages: []string{"pt-br", "en"}}, 4},
+		{"primary language only", visitorInfo{Languages: []string{"pt", "en"}}, 2},
+		{"os before a later language", visitorInfo{OS: "ios", Languages: []string{"de"}}, 3},
+		{"os", visitorInfo{OS: "ios", Languages: []string{"fr"}}, 3},
+		{"none", visitorInfo{Country: "MY", OS: "android"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			if rule := matchRule(rules, tt.visitor); rule != nil {
+				got = rule.ID
+			}
+			if got != tt.want {
+				t.Errorf("matchRule = rule %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,23 +3,32 @@ package handlers
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
 	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/themecss"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ThemeHandler struct {
 	themeRepo   *repository.ThemeRepository
+	presetRepo  *repository.ThemePresetRepository
+	versionRepo *repository.ThemeVersionRepository
 	profileRepo *repository.ProfileRepository
+	userRepo    *repository.UserRepository
 }
 
 func NewThemeHandler(db *pgxpool.Pool) *ThemeHandler {
 	return &ThemeHandler{
 		themeRepo:   repository.NewThemeRepository(db),
+		presetRepo:  repository.NewThemePresetRepository(db),
+		versionRepo: repository.NewThemeVersionRepository(db),
 		profileRepo: repository.NewProfileRepository(db),
+		userRepo:    repository.NewUserRepository(db),
 	}
 }
 
@@ -78,6 +87,9 @@ func (h *ThemeHandler) UpdateTheme(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return ValidationError(c, "Invalid request body")
 	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
 	// Update fields if provided
 	if req.BgType != nil {
@@ -134,8 +146,28 @@ func (h *ThemeHandler) UpdateTheme(c *fiber.Ctx) error {
 	if req.BoxedShadow != nil {
 		theme.BoxedShadow = *req.BoxedShadow
 	}
+	if req.CustomCSS != nil {
+		if strings.TrimSpace(*req.CustomCSS) == "" {
+			theme.CustomCSS = nil
+		} else {
+			// Removing custom CSS stays possible after premium ends
+			user, err := h.userRepo.GetByID(ctx, userID)
+			if err != nil {
+				return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch user")
+			}
+			if !user.IsPremium {
+				return ErrorResponse(c, fiber.StatusForbidden, "Custom CSS requires a premium account")
+			}
+			theme.CustomCSS = req.CustomCSS
+		}
+	}
+
+	// Values end up in page styles, so the whole theme must be valid
+	if errs := themecss.Validate(&theme.ThemeSettings); errs != nil {
+		return ValidationFailed(c, errs)
+	}
 
-	if err := h.themeRepo.Update(ctx, theme); err != nil {
+	if err := h.themeRepo.Update(ctx, theme, models.ThemeSourceUpdate); err != nil {
 		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update theme")
 	}
 
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/themecss"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetHistory returns the saved versions of a profile's theme, newest first
+func (h *ThemeHandler) GetHistory(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("profileId")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	versions, err := h.versionRepo.ListByProfileID(ctx, profileID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme history")
+	}
+
+	return SuccessResponse(c, versions)
+}
+
+// RestoreVersion makes an earlier version the current theme. The restore is
+// itself recorded as a new version, so it can be undone too.
+func (h *ThemeHandler) RestoreVersion(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("profileId")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+	versionNumber, err := c.ParamsInt("version")
+	if err != nil {
+		return ValidationError(c, "Invalid version")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	version, err := h.versionRepo.Get(ctx, profileID, versionNumber)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Theme version")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme version")
+	}
+
+	theme, err := h.themeRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Theme")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme")
+	}
+
+	// The version may be from when the account was premium
+	allowed, err := h.premiumSettingsAllowed(ctx, userID, theme, &version.Settings)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch user")
+	}
+	if !allowed {
+		return ErrorResponse(c, fiber.StatusForbidden, "This version uses custom CSS or fonts, which require a premium account")
+	}
+
+	// Versions saved before validation tightened may no longer be valid
+	theme.ThemeSettings = version.Settings
+	if errs := themecss.Validate(&theme.ThemeSettings); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	if err := h.themeRepo.Update(ctx, theme, models.ThemeSourceRestore); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to restore theme")
+	}
+
+	return SuccessResponse(c, theme)
+}
+
+// Helper: Check whether settings may replace those of a theme. Keeping the
+// custom CSS or font the theme already has is fine, bringing them back
+// takes a premium account.
+func (h *ThemeHandler) premiumSettingsAllowed(ctx context.Context, userID int, theme *models.Theme, settings *models.ThemeSettings) (bool, error) {
+	addsCSS := settings.CustomCSS != nil && !equalStringPtr(settings.CustomCSS, theme.CustomCSS)
+	addsFont := settings.CustomFontURL != nil && !equalStringPtr(settings.CustomFontURL, theme.CustomFontURL)
+	if !addsCSS && !addsFont {
+		return true, nil
+	}
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsPremium, nil
+}
+
+// Helper: Compare two optional strings
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/themecss"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Most presets a user can save
+const maxPersonalPresets = 20
+
+// GetPresets returns the built-in theme presets and the user's own
+func (h *ThemeHandler) GetPresets(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	presets, err := h.presetRepo.ListForUser(context.Background(), userID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch presets")
+	}
+
+	return SuccessResponse(c, presets)
+}
+
+// ApplyPreset replaces the theme of a profile with a preset
+func (h *ThemeHandler) ApplyPreset(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("profileId")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	var req models.ApplyThemePresetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	preset, err := h.presetRepo.GetForUser(ctx, req.PresetID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Preset")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch preset")
+	}
+
+	theme, err := h.themeRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Theme")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme")
+	}
+
+	// Personal presets may be from when the account was premium
+	allowed, err := h.premiumSettingsAllowed(ctx, userID, theme, &preset.Settings)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch user")
+	}
+	if !allowed {
+		return ErrorResponse(c, fiber.StatusForbidden, "This preset uses custom CSS or fonts, which require a premium account")
+	}
+
+	// Presets saved before validation tightened may no longer be valid
+	theme.ThemeSettings = preset.Settings
+	if errs := themecss.Validate(&theme.ThemeSettings); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	if err := h.themeRepo.Update(ctx, theme, models.ThemeSourcePreset); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update theme")
+	}
+
+	return SuccessResponse(c, theme)
+}
+
+// CreatePreset saves the current theme of a profile as a personal preset,
+// available to all of the user's profiles
+func (h *ThemeHandler) CreatePreset(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var req models.CreateThemePresetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ValidationError(c, "Invalid request body")
+	}
+	if errs := validation.Struct(req); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, req.ProfileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	count, err := h.presetRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to save preset")
+	}
+	if count >= maxPersonalPresets {
+		return ErrorResponse(c, fiber.StatusUnprocessableEntity, fmt.Sprintf("You can save up to %d presets", maxPersonalPresets))
+	}
+
+	theme, err := h.themeRepo.GetByProfileID(ctx, req.ProfileID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Theme")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme")
+	}
+
+	// Themes saved before validation may hold values presets must not spread
+	if errs := themecss.Validate(&theme.ThemeSettings); errs != nil {
+		return ValidationFailed(c, errs)
+	}
+
+	preset := &models.ThemePreset{
+		UserID:   &userID,
+		Name:     req.Name,
+		Settings: theme.ThemeSettings,
+	}
+	if err := h.presetRepo.Create(ctx, preset); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return ErrorResponse(c, fiber.StatusConflict, "You already have a preset with this name")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to save preset")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    preset,
+	})
+}
+
+// DeletePreset deletes a personal preset
+func (h *ThemeHandler) DeletePreset(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	presetID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid preset ID")
+	}
+
+	if err := h.presetRepo.Delete(context.Background(), presetID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Preset")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete preset")
+	}
+
+	return SuccessResponse(c, fiber.Map{"message": "Preset deleted"})
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/imageproc"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/middleware"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Largest accepted upload per asset kind. The server's body limit must be
+// above the largest one.
+const (
+	maxAvatarUpload     = 5 << 20
+	maxBackgroundUpload = 10 << 20
+	maxFontUpload       = 2 << 20
+)
+
+type UploadHandler struct {
+	profileRepo *repository.ProfileRepository
+	themeRepo   *repository.ThemeRepository
+	assetRepo   *repository.AssetRepository
+	userRepo    *repository.UserRepository
+	storage     storage.Storage
+}
+
+func NewUploadHandler(db *pgxpool.Pool, store storage.Storage) *UploadHandler {
+	return &UploadHandler{
+		profileRepo: repository.NewProfileRepository(db),
+		themeRepo:   repository.NewThemeRepository(db),
+		assetRepo:   repository.NewAssetRepository(db),
+		userRepo:    repository.NewUserRepository(db),
+		storage:     store,
+	}
+}
+
+// UploadAvatar replaces the avatar of a profile with an uploaded image,
+// center-cropped to a square
+func (h *UploadHandler) UploadAvatar(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("id")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	result, fieldErrs, err := readUploadedImage(c, imageproc.Avatar, maxAvatarUpload)
+	if fieldErrs != nil {
+		return ValidationFailed(c, fieldErrs)
+	}
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to process image")
+	}
+
+	asset, err := h.saveAsset(ctx, userID, profileID, models.AssetAvatar, result)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to store image")
+	}
+
+	if err := h.profileRepo.SetAvatar(ctx, profileID, asset.URL); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Profile")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update avatar")
+	}
+
+	profile, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch profile")
+	}
+
+	return SuccessResponse(c, fiber.Map{
+		"asset":   asset,
+		"profile": profile,
+	})
+}
+
+// UploadBackground sets an uploaded image as the page background of a
+// profile's theme
+func (h *UploadHandler) UploadBackground(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("profileId")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	result, fieldErrs, err := readUploadedImage(c, imageproc.Background, maxBackgroundUpload)
+	if fieldErrs != nil {
+		return ValidationFailed(c, fieldErrs)
+	}
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to process image")
+	}
+
+	asset, err := h.saveAsset(ctx, userID, profileID, models.AssetBackground, result)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to store image")
+	}
+
+	if err := h.themeRepo.SetBackgroundImage(ctx, profileID, asset.URL); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Theme")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update theme")
+	}
+
+	theme, err := h.themeRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme")
+	}
+
+	return SuccessResponse(c, fiber.Map{
+		"asset": asset,
+		"theme": theme,
+	})
+}
+
+// UploadFont sets an uploaded font file as the font of a profile's theme.
+// Premium only.
+func (h *UploadHandler) UploadFont(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("profileId")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch user")
+	}
+	if !user.IsPremium {
+		return ErrorResponse(c, fiber.StatusForbidden, "Custom fonts require a premium account")
+	}
+
+	data, fieldErrs, err := readUploadedFile(c, maxFontUpload)
+	if fieldErrs != nil {
+		return ValidationFailed(c, fieldErrs)
+	}
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read font")
+	}
+
+	// The content decides the type, not the file name or the client's header
+	contentType, ext, ok := sniffFont(data)
+	if !ok {
+		return ValidationFailed(c, []models.FieldError{
+			{Field: "file", Rule: "font", Message: "file must be a WOFF2, WOFF, TrueType or OpenType font"},
+		})
+	}
+
+	asset := &models.Asset{
+		UserID:      &userID,
+		ProfileID:   &profileID,
+		Kind:        models.AssetFont,
+		ContentType: contentType,
+	}
+	if err := h.putAsset(ctx, asset, data, ext); err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to store font")
+	}
+
+	if err := h.themeRepo.SetCustomFont(ctx, profileID, &asset.URL); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Theme")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update theme")
+	}
+
+	theme, err := h.themeRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme")
+	}
+
+	return SuccessResponse(c, fiber.Map{
+		"asset": asset,
+		"theme": theme,
+	})
+}
+
+// DeleteFont removes the uploaded font of a profile's theme. The file is
+// collected once unused.
+func (h *UploadHandler) DeleteFont(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	profileID, err := c.ParamsInt("profileId")
+	if err != nil {
+		return ValidationError(c, "Invalid profile ID")
+	}
+
+	ctx := context.Background()
+
+	// Check ownership
+	belongs, err := h.profileRepo.BelongsToUser(ctx, profileID, userID)
+	if err != nil || !belongs {
+		return Forbidden(c)
+	}
+
+	if err := h.themeRepo.SetCustomFont(ctx, profileID, nil); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFound(c, "Theme")
+		}
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update theme")
+	}
+
+	theme, err := h.themeRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		return ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch theme")
+	}
+
+	return SuccessResponse(c, theme)
+}
+
+// Helper: Read and process the image in the "file" form field. Problems
+// with the upload itself are returned as field errors.
+func readUploadedImage(c *fiber.Ctx, variant imageproc.Variant, maxBytes int64) (*imageproc.Result, []models.FieldError, error) {
+	data, fieldErrs, err := readUploadedFile(c, maxBytes)
+	if fieldErrs != nil || err != nil {
+		return nil, fieldErrs, err
+	}
+
+	// The content decides the type, not the file name or the client's header
+	result, err := imageproc.Process(data, variant)
+	switch {
+	case errors.Is(err, imageproc.ErrUnsupported):
+		return nil, []models.FieldError{{Field: "file", Rule: "image", Message: "file must be a JPEG, PNG, GIF or WebP image"}}, nil
+	case errors.Is(err, imageproc.ErrTooLarge):
+		return nil, []models.FieldError{{Field: "file", Rule: "dimensions", Message: "image dimensions are too large"}}, nil
+	case err != nil:
+		return nil, nil, err
+	}
+	return result, nil, nil
+}
+
+// Helper: Read the "file" form field, at most maxBytes of it
+func readUploadedFile(c *fiber.Ctx, maxBytes int64) ([]byte, []models.FieldError, error) {
+	tooLarge := []models.FieldError{
+		{Field: "file", Rule: "max", Message: fmt.Sprintf("file must be at most %d MB", maxBytes>>20)},
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, []models.FieldError{{Field: "file", Rule: "required", Message: "file is required"}}, nil
+	}
+	if fileHeader.Size > maxBytes {
+		return nil, tooLarge, nil
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, tooLarge, nil
+	}
+	return data, nil, nil
+}
+
+// Helper: Detect a font file by its signature
+func sniffFont(data []byte) (contentType, ext string, ok bool) {
+	if len(data) < 4 {
+		return "", "", false
+	}
+	switch string(data[:4]) {
+	case "wOF2":
+		return "font/woff2", "woff2", true
+	case "wOFF":
+		return "font/woff", "woff", true
+	case "\x00\x01\x00\x00", "true":
+		return "font/ttf", "ttf", true
+	case "OTTO":
+		return "font/otf", "otf", true
+	}
+	return "", "", false
+}
+
+// Helper: Store a processed image and record it as an asset of the profile
+func (h *UploadHandler) saveAsset(ctx context.Context, userID, profileID int, kind string, result *imageproc.Result) (*models.Asset, error) {
+	asset := &models.Asset{
+		UserID:      &userID,
+		ProfileID:   &profileID,
+		Kind:        kind,
+		ContentType: result.ContentType,
+		Width:       result.Width,
+		Height:      result.Height,
+	}
+	if err := h.putAsset(ctx, asset, result.Data, result.Ext); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// Helper: Store data and record it as the asset. Keys are content-addressed:
+// a changed file gets a new URL, so stored assets can be cached forever.
+func (h *UploadHandler) putAsset(ctx context.Context, asset *models.Asset, data []byte, ext string) error {
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%ss/%d/%s.%s", asset.Kind, *asset.ProfileID, hex.EncodeToString(sum[:12]), ext)
+	if err := h.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), asset.ContentType); err != nil {
+		return err
+	}
+
+	asset.StorageKey = key
+	asset.URL = h.storage.URL(key)
+	asset.SizeBytes = len(data)
+	return h.assetRepo.Create(ctx, asset)
+}
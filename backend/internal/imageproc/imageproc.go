@@ -0,0 +1,183 @@
+// Package imageproc validates uploaded images and normalizes them: the
+// content type is sniffed rather than trusted, EXIF orientation is applied,
+// metadata (EXIF, GPS, ICC) is stripped by re-encoding, and the image is
+// resized or cropped to a standard size. Opaque images become JPEGs;
+// transparent ones a lossless WebP or a PNG, whichever is smaller.
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image dimensions too large")
+)
+
+// Largest image decoded, in pixels
+const maxPixels = 16_000_000
+
+const jpegQuality = 85
+
+// Accepted upload formats
+var accepted = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Variant describes the standard size of a kind of image
+type Variant struct {
+	Width  int
+	Height int
+	Crop   bool // Fill the box exactly by center-cropping; otherwise fit inside without upscaling
+}
+
+var (
+	Avatar     = Variant{Width: 400, Height: 400, Crop: true}
+	Background = Variant{Width: 1920, Height: 1920}
+)
+
+// Result is a processed image
+type Result struct {
+	Data        []byte
+	ContentType string
+	Ext         string // File extension of the content type
+	Width       int
+	Height      int
+}
+
+// Sniff returns the content type of data if it is an accepted image format
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !accepted[contentType] {
+		return "", ErrUnsupported
+	}
+	return contentType, nil
+}
+
+// Process decodes an uploaded image and re-encodes it at the variant's size:
+// as a JPEG, or keeping its transparency when it has any.
+func Process(data []byte, v Variant) (*Result, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check dimensions before allocating the full image
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = exifOrientation(data)
+	}
+
+	// Resize first so the orientation transform works on few pixels. Center
+	// crops and scaling are symmetric, so the order does not matter once the
+	// box is rotated along with the image.
+	box := v
+	if orientation >= 5 {
+		box.Width, box.Height = v.Height, v.Width
+	}
+	opaque := isOpaque(src)
+	img := orient(resize(src, box, opaque), orientation)
+
+	result, err := encode(img, opaque)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	result.Width, result.Height = b.Dx(), b.Dy()
+	return result, nil
+}
+
+// encode encodes img as a JPEG when it is opaque. Otherwise it is encoded
+// both as a lossless WebP and a PNG, and the smaller one is kept.
+func encode(img *image.RGBA, opaque bool) (*Result, error) {
+	var buf bytes.Buffer
+	if opaque {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		return &Result{Data: buf.Bytes(), ContentType: "image/jpeg", Ext: "jpg"}, nil
+	}
+
+	nrgba := image.NewNRGBA(img.Bounds())
+	draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	if err := png.Encode(&buf, nrgba); err != nil {
+		return nil, err
+	}
+	result := &Result{Data: buf.Bytes(), ContentType: "image/png", Ext: "png"}
+
+	var webp bytes.Buffer
+	if err := encodeWebP(&webp, nrgba); err != nil {
+		return nil, err
+	}
+	if webp.Len() < buf.Len() {
+		result = &Result{Data: webp.Bytes(), ContentType: "image/webp", Ext: "webp"}
+	}
+	return result, nil
+}
+
+// isOpaque reports whether img has no transparent pixels
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// resize scales src to the variant. Opaque images are drawn over white, so
+// that rounding at the edges leaves no transparent pixels.
+func resize(src image.Image, v Variant, opaque bool) *image.RGBA {
+	sb := src.Bounds()
+	w, h := sb.Dx(), sb.Dy()
+
+	var srcRect image.Rectangle
+	var dw, dh int
+	if v.Crop {
+		// Largest centered region with the box's aspect ratio
+		cw, ch := w, w*v.Height/v.Width
+		if ch > h {
+			cw, ch = h*v.Width/v.Height, h
+		}
+		x, y := sb.Min.X+(w-cw)/2, sb.Min.Y+(h-ch)/2
+		srcRect = image.Rect(x, y, x+cw, y+ch)
+		dw, dh = v.Width, v.Height
+	} else {
+		srcRect = sb
+		scale := min(1, float64(v.Width)/float64(w), float64(v.Height)/float64(h))
+		dw, dh = max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	if opaque {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Over, nil)
+	return dst
+}
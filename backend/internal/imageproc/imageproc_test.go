@@ -0,0 +1,229 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessFormats(t *testing.T) {
+	opaque := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	transparent := image.NewNRGBA(image.Rect(0, 0, 600, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 600; x++ {
+			opaque.Set(x, y, color.RGBA{uint8(x), uint8(y), 80, 255})
+			if x > 200 {
+				transparent.Set(x, y, color.NRGBA{200, 30, 30, 255})
+			}
+		}
+	}
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, opaque, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		variant     Variant
+		contentType []string
+		width       int
+		height      int
+	}{
+		{"jpeg", jpegData.Bytes(), Avatar, []string{"image/jpeg"}, 400, 400},
+		{"opaque png", encodePNG(t, opaque), Background, []string{"image/jpeg"}, 600, 300},
+		{"transparent png", encodePNG(t, transparent), Avatar, []string{"image/png", "image/webp"}, 400, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data, tt.variant)
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if result.Width != tt.width || result.Height != tt.height {
+				t.Errorf("size = %dx%d, want %dx%d", result.Width, result.Height, tt.width, tt.height)
+			}
+
+			sniffed, err := Sniff(result.Data)
+			if err != nil {
+				t.Fatalf("output not sniffed: %v", err)
+			}
+			if sniffed != result.ContentType {
+				t.Errorf("content type %s, data is %s", result.ContentType, sniffed)
+			}
+			ok := false
+			for _, ct := range tt.contentType {
+				ok = ok || ct == result.ContentType
+			}
+			if !ok {
+				t.Errorf("content type = %s, want one of %v", result.ContentType, tt.contentType)
+			}
+			wantExt := map[string]string{"image/jpeg": "jpg", "image/png": "png", "image/webp": "webp"}[result.ContentType]
+			if result.Ext != wantExt {
+				t.Errorf("ext = %q, want %q", result.Ext, wantExt)
+			}
+
+			img, _, err := image.Decode(bytes.NewReader(result.Data))
+			if err != nil {
+				t.Fatalf("decoding output: %v", err)
+			}
+			if result.ContentType != "image/jpeg" {
+				// The transparent left third survives the crop
+				if _, _, _, a := img.At(0, 200).RGBA(); a != 0 {
+					t.Errorf("alpha at left edge = %d, want 0", a)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	huge := image.NewGray(image.Rect(0, 0, 5000, 4000))
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"truncated png", []byte("\x89PNG\r\n\x1a\n"), ErrUnsupported},
+		{"text", []byte("hello"), ErrUnsupported},
+		{"too many pixels", encodePNG(t, huge), ErrTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data, Background); !errors.Is(err, tt.want) {
+				t.Errorf("Process error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := image.NewNRGBA(image.Rect(0, 0, 97, 61))
+	rng.Read(noise.Pix)
+
+	gradient := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			gradient.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), uint8(x + y), uint8(255 - y)})
+		}
+	}
+
+	// Few colors in repeating stripes: long backward references
+	stripes := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			c := color.NRGBA{0, 0, 0, 0}
+			if (x/7+y/5)%3 == 0 {
+				c = color.NRGBA{255, 128, 0, 255}
+			}
+			stripes.SetNRGBA(x, y, c)
+		}
+	}
+
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+	}{
+		{"single pixel", image.NewNRGBA(image.Rect(0, 0, 1, 1))},
+		{"uniform", func() *image.NRGBA {
+			img := image.NewNRGBA(image.Rect(0, 0, 50, 50))
+			for i := range img.Pix {
+				img.Pix[i] = 0x80
+			}
+			return img
+		}()},
+		{"noise", noise},
+		{"gradient", gradient},
+		{"stripes", stripes},
+		{"sub-image", gradient.SubImage(image.Rect(10, 20, 110, 90)).(*image.NRGBA)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeWebP(&buf, tt.img); err != nil {
+				t.Fatalf("encodeWebP: %v", err)
+			}
+			decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+
+			b := tt.img.Bounds()
+			if got := decoded.Bounds(); got.Dx() != b.Dx() || got.Dy() != b.Dy() {
+				t.Fatalf("bounds = %v, want %v", got, b)
+			}
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					want := tt.img.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+					got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					if got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPrefixEncode(t *testing.T) {
+	// Inverse of the decoder's prefix code, section 4.2.2 of the spec
+	decode := func(symbol int, extra uint32) int {
+		if symbol < 4 {
+			return symbol + 1
+		}
+		extraBits := (symbol - 2) >> 1
+		offset := (2 + symbol&1) << extraBits
+		return offset + int(extra) + 1
+	}
+	for _, value := range []int{1, 2, 4, 5, 6, 7, 8, 9, 100, 4095, 4096, 1920, 1 << 20} {
+		symbol, extraBits, extra := prefixEncode(value)
+		if extra >= 1<<extraBits {
+			t.Errorf("prefixEncode(%d): extra %d does not fit %d bits", value, extra, extraBits)
+		}
+		if got := decode(symbol, extra); got != value {
+			t.Errorf("prefixEncode(%d) = symbol %d, extra %d, decodes to %d", value, symbol, extra, got)
+		}
+	}
+}
+
+func TestNewPrefixCodeLimit(t *testing.T) {
+	// Fibonacci counts make the deepest Huffman trees
+	histogram := make([]int, 30)
+	a, b := 1, 1
+	for i := range histogram {
+		histogram[i] = a
+		a, b = b, a+b
+	}
+
+	for _, limit := range []int{maxCodeLengthCodeLen, maxCodeLength} {
+		code := newPrefixCode(histogram, limit)
+		kraft := 0.0
+		for symbol, l := range code.lengths {
+			if l == 0 || int(l) > limit {
+				t.Fatalf("limit %d: symbol %d has length %d", limit, symbol, l)
+			}
+			kraft += 1 / float64(uint(1)<<l)
+		}
+		if kraft != 1 {
+			t.Errorf("limit %d: Kraft sum = %v, want a complete code", limit, kraft)
+		}
+	}
+}
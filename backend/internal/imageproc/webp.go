@@ -0,0 +1,602 @@
+package imageproc
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+	"sort"
+)
+
+// Lossless WebP (VP8L) encoder. It applies the subtract-green and predictor
+// transforms, finds LZ77 backward references with a hash chain and codes the
+// result with a single set of prefix codes. The format is specified at
+// https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+
+const (
+	vp8lSignature        = 0x2f
+	vp8lMaxDimension     = 1 << 14
+	predictor            = 0
+	subtractGreen        = 2
+	predictorBits        = 5 // Predictor tiles are 32x32 pixels
+	literalCodes         = 256
+	lengthCodes          = 24
+	distanceCodes        = 40
+	maxCodeLength        = 15
+	maxCodeLengthCodeLen = 7
+
+	minMatch      = 3
+	maxMatch      = 4096
+	maxChainDepth = 32
+	hashBits      = 16
+	// Distances are sent offset by the 120 short-distance codes
+	maxDistance = 1<<20 - 120
+)
+
+// Predictors tried for each tile: L, T, Average2(L, T), Select and
+// ClampAddSubtractFull
+var predictorModes = []int{1, 2, 7, 11, 12}
+
+// Order in which the lengths of the code length code are sent
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// token is a literal pixel or, when length > 0, a backward reference
+type token struct {
+	argb     uint32
+	length   int
+	distance int
+}
+
+// encodeWebP writes img as a lossless WebP
+func encodeWebP(w io.Writer, img *image.NRGBA) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return ErrTooLarge
+	}
+
+	pixels := make([]uint32, 0, width*height)
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := 0; x < width; x++ {
+			r, g, bl, a := row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]
+			opaque = opaque && a == 0xff
+			pixels = append(pixels, uint32(a)<<24|uint32(r-g)<<16|uint32(g)<<8|uint32(bl-g))
+		}
+	}
+	modes, tilesPerRow := choosePredictors(pixels, width, height)
+	residuals := predictorResiduals(pixels, width, height, modes, tilesPerRow)
+
+	var bw bitWriter
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.writeBool(!opaque)
+	bw.write(0, 3) // Version
+	bw.writeBool(true)
+	bw.write(subtractGreen, 2)
+	bw.writeBool(true)
+	bw.write(predictor, 2)
+	bw.write(predictorBits-2, 3)
+	writeImage(&bw, modes, tilesPerRow, false)
+	bw.writeBool(false) // No further transforms
+	writeImage(&bw, residuals, width, true)
+	payload := bw.bytes()
+
+	padding := len(payload) & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(payload)+padding))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if padding == 1 {
+		payload = append(payload, 0)
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// writeImage writes the entropy-coded pixels of an image: the main image
+// when topLevel, otherwise a transform's sub-image
+func writeImage(bw *bitWriter, pixels []uint32, width int, topLevel bool) {
+	tokens := backwardReferences(pixels, width)
+
+	// Histograms of the green (with lengths), red, blue, alpha and distance
+	// alphabets
+	histograms := [5][]int{
+		make([]int, literalCodes+lengthCodes),
+		make([]int, literalCodes),
+		make([]int, literalCodes),
+		make([]int, literalCodes),
+		make([]int, distanceCodes),
+	}
+	for _, t := range tokens {
+		if t.length > 0 {
+			lengthSymbol, _, _ := prefixEncode(t.length)
+			distanceSymbol, _, _ := prefixEncode(t.distance + 120)
+			histograms[0][literalCodes+lengthSymbol]++
+			histograms[4][distanceSymbol]++
+			continue
+		}
+		histograms[0][t.argb>>8&0xff]++
+		histograms[1][t.argb>>16&0xff]++
+		histograms[2][t.argb&0xff]++
+		histograms[3][t.argb>>24]++
+	}
+
+	bw.writeBool(false) // No color cache
+	if topLevel {
+		bw.writeBool(false) // No meta prefix codes
+	}
+	var codes [5]prefixCode
+	for i, histogram := range histograms {
+		codes[i] = writePrefixCode(bw, histogram)
+	}
+
+	for _, t := range tokens {
+		if t.length > 0 {
+			symbol, extraBits, extra := prefixEncode(t.length)
+			codes[0].writeSymbol(bw, literalCodes+symbol)
+			bw.write(extra, extraBits)
+			symbol, extraBits, extra = prefixEncode(t.distance + 120)
+			codes[4].writeSymbol(bw, symbol)
+			bw.write(extra, extraBits)
+			continue
+		}
+		codes[0].writeSymbol(bw, int(t.argb>>8&0xff))
+		codes[1].writeSymbol(bw, int(t.argb>>16&0xff))
+		codes[2].writeSymbol(bw, int(t.argb&0xff))
+		codes[3].writeSymbol(bw, int(t.argb>>24))
+	}
+}
+
+// choosePredictors picks the predictor of each tile that leaves the smallest
+// residuals. The modes are returned as the predictor sub-image, which holds
+// them in the green channel.
+func choosePredictors(pixels []uint32, width, height int) (modes []uint32, tilesPerRow int) {
+	tilesPerRow = (width + 1<<predictorBits - 1) >> predictorBits
+	tileRows := (height + 1<<predictorBits - 1) >> predictorBits
+	modes = make([]uint32, tilesPerRow*tileRows)
+
+	for ty := 0; ty < tileRows; ty++ {
+		for tx := 0; tx < tilesPerRow; tx++ {
+			best, bestCost := predictorModes[0], -1
+			for _, mode := range predictorModes {
+				cost := 0
+				for y := max(ty<<predictorBits, 1); y < min((ty+1)<<predictorBits, height); y++ {
+					for x := max(tx<<predictorBits, 1); x < min((tx+1)<<predictorBits, width); x++ {
+						i := y*width + x
+						cost += residualCost(subPixels(pixels[i], predict(mode, pixels, i, width)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[ty*tilesPerRow+tx] = 0xff000000 | uint32(best)<<8
+		}
+	}
+	return modes, tilesPerRow
+}
+
+// predictorResiduals returns the difference of each pixel to its prediction.
+// The first pixel is predicted as opaque black, the rest of the first row
+// from the left and the first column from the top.
+func predictorResiduals(pixels []uint32, width, height int, modes []uint32, tilesPerRow int) []uint32 {
+	residuals := make([]uint32, len(pixels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var prediction uint32
+			switch {
+			case i == 0:
+				prediction = 0xff000000
+			case y == 0:
+				prediction = pixels[i-1]
+			case x == 0:
+				prediction = pixels[i-width]
+			default:
+				mode := int(modes[(y>>predictorBits)*tilesPerRow+x>>predictorBits] >> 8 & 0x0f)
+				prediction = predict(mode, pixels, i, width)
+			}
+			residuals[i] = subPixels(pixels[i], prediction)
+		}
+	}
+	return residuals
+}
+
+// predict returns the prediction of pixel i for one of predictorModes. The
+// pixel must have neighbors to the left and top.
+func predict(mode int, pixels []uint32, i, width int) uint32 {
+	l, t, tl := pixels[i-1], pixels[i-width], pixels[i-width-1]
+	switch mode {
+	case 1:
+		return l
+	case 2:
+		return t
+	case 7: // Average2(L, T)
+		return (l & t) + ((l ^ t) & 0xfefefefe >> 1)
+	case 11: // Select(L, T, TL): whichever is closer to L + T - TL
+		var distL, distT int
+		for shift := 0; shift < 32; shift += 8 {
+			c := int(tl >> shift & 0xff)
+			distL += abs(c - int(t>>shift&0xff))
+			distT += abs(c - int(l>>shift&0xff))
+		}
+		if distL < distT {
+			return l
+		}
+		return t
+	case 12: // ClampAddSubtractFull(L, T, TL)
+		var p uint32
+		for shift := 0; shift < 32; shift += 8 {
+			c := int(l>>shift&0xff) + int(t>>shift&0xff) - int(tl>>shift&0xff)
+			p |= uint32(min(max(c, 0), 255)) << shift
+		}
+		return p
+	}
+	return 0xff000000
+}
+
+// subPixels subtracts b from a channel by channel
+func subPixels(a, b uint32) uint32 {
+	var d uint32
+	for shift := 0; shift < 32; shift += 8 {
+		d |= (a>>shift - b>>shift) & 0xff << shift
+	}
+	return d
+}
+
+// residualCost estimates the cost of coding a residual: its distance to zero
+func residualCost(residual uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(residual >> shift & 0xff)
+		cost += min(v, 256-v)
+	}
+	return cost
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// backwardReferences turns pixels into literals and backward references.
+// Besides the hash chain, the pixel to the left and the one above are always
+// tried, as they match most often.
+func backwardReferences(pixels []uint32, width int) []token {
+	n := len(pixels)
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	chain := make([]int32, n)
+	hash := func(i int) uint32 {
+		return (pixels[i]*0x1e35a7bd ^ pixels[i+1]*0x9e3779b1) >> (32 - hashBits)
+	}
+	insert := func(i int) {
+		if i+1 < n {
+			h := hash(i)
+			chain[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+	matchLength := func(i, j int) int {
+		limit := min(n-i, maxMatch)
+		l := 0
+		for l < limit && pixels[i+l] == pixels[j+l] {
+			l++
+		}
+		return l
+	}
+
+	tokens := make([]token, 0, n/2)
+	for i := 0; i < n; {
+		bestLength, bestDistance := 0, 0
+		try := func(j int) {
+			if j < 0 || i-j > maxDistance {
+				return
+			}
+			if l := matchLength(i, j); l > bestLength {
+				bestLength, bestDistance = l, i-j
+			}
+		}
+		if i+1 < n {
+			try(i - 1)
+			try(i - width)
+			for j, depth := int(head[hash(i)]), 0; j >= 0 && depth < maxChainDepth && bestLength < maxMatch; j, depth = int(chain[j]), depth+1 {
+				try(j)
+			}
+		}
+
+		if bestLength < minMatch {
+			tokens = append(tokens, token{argb: pixels[i]})
+			insert(i)
+			i++
+			continue
+		}
+		tokens = append(tokens, token{length: bestLength, distance: bestDistance})
+		for end := i + bestLength; i < end; i++ {
+			insert(i)
+		}
+	}
+	return tokens
+}
+
+// prefixEncode splits a length or distance value into its prefix symbol and
+// extra bits
+func prefixEncode(value int) (symbol int, extraBits uint, extra uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	high := 31
+	for d>>high == 0 {
+		high--
+	}
+	second := d >> (high - 1) & 1
+	extraBits = uint(high - 1)
+	return 2*high + second, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+// prefixCode is a canonical Huffman code
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+	// Codes with a single symbol take no bits
+	single bool
+}
+
+func (p *prefixCode) writeSymbol(bw *bitWriter, symbol int) {
+	if p.single {
+		return
+	}
+	// The decoder reads codes from their most significant bit
+	length := uint(p.lengths[symbol])
+	code, reversed := uint32(p.codes[symbol]), uint32(0)
+	for i := uint(0); i < length; i++ {
+		reversed = reversed<<1 | code>>i&1
+	}
+	bw.write(reversed, length)
+}
+
+// writePrefixCode writes the prefix code for histogram and returns it. Up to
+// two symbols below 256 use the simple code; others send their code lengths,
+// themselves coded with a code length code.
+func writePrefixCode(bw *bitWriter, histogram []int) prefixCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		code := prefixCode{lengths: make([]uint8, len(histogram)), codes: make([]uint16, len(histogram))}
+		if len(used) == 0 {
+			// Unused alphabet: any valid code
+			used = []int{0}
+		}
+		bw.writeBool(true)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+			code.lengths[used[0]], code.lengths[used[1]] = 1, 1
+			code.codes[used[1]] = 1
+		} else {
+			code.single = true
+		}
+		return code
+	}
+
+	code := newPrefixCode(histogram, maxCodeLength)
+	bw.writeBool(false)
+
+	// Code lengths, run-length coded: 16 repeats the previous non-zero
+	// length 3-6 times, 17 and 18 repeat zero 3-10 and 11-138 times
+	type run struct {
+		symbol int
+		extra  uint32
+	}
+	var runs []run
+	lengths := code.lengths
+	for i := 0; i < len(lengths); {
+		value, count := lengths[i], 1
+		for i+count < len(lengths) && lengths[i+count] == value {
+			count++
+		}
+		i += count
+		if value == 0 {
+			for count >= 3 {
+				if count >= 11 {
+					n := min(count, 138)
+					runs = append(runs, run{18, uint32(n - 11)})
+					count -= n
+				} else {
+					n := min(count, 10)
+					runs = append(runs, run{17, uint32(n - 3)})
+					count -= n
+				}
+			}
+		} else {
+			runs = append(runs, run{symbol: int(value)})
+			count--
+			for count >= 3 {
+				n := min(count, 6)
+				runs = append(runs, run{16, uint32(n - 3)})
+				count -= n
+			}
+		}
+		for ; count > 0; count-- {
+			runs = append(runs, run{symbol: int(value)})
+		}
+	}
+
+	histogram = make([]int, len(codeLengthCodeOrder))
+	for _, r := range runs {
+		histogram[r.symbol]++
+	}
+	lengthCode := newPrefixCode(histogram, maxCodeLengthCodeLen)
+	sent := len(codeLengthCodeOrder)
+	for sent > 4 && lengthCode.lengths[codeLengthCodeOrder[sent-1]] == 0 {
+		sent--
+	}
+	bw.write(uint32(sent-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:sent] {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+
+	bw.writeBool(false) // All code lengths are sent
+	repeatBits := map[int]uint{16: 2, 17: 3, 18: 7}
+	for _, r := range runs {
+		lengthCode.writeSymbol(bw, r.symbol)
+		if bits := repeatBits[r.symbol]; bits > 0 {
+			bw.write(r.extra, bits)
+		}
+	}
+	return code
+}
+
+// newPrefixCode builds a Huffman code for histogram with codes of at most
+// limit bits. Too long codes are avoided by raising the rarest counts until
+// the tree is flat enough.
+func newPrefixCode(histogram []int, limit int) prefixCode {
+	code := prefixCode{lengths: make([]uint8, len(histogram)), codes: make([]uint16, len(histogram))}
+
+	total, used := 0, 0
+	for _, count := range histogram {
+		total += count
+		if count > 0 {
+			used++
+		}
+	}
+	if used <= 1 {
+		for symbol, count := range histogram {
+			if count > 0 {
+				code.lengths[symbol] = 1
+			}
+		}
+		code.single = true
+		return code
+	}
+
+	for floor := 0; ; floor = max(2*floor, 1) {
+		if huffmanLengths(histogram, floor, code.lengths) <= limit {
+			break
+		}
+	}
+
+	// Canonical codes, in symbol order within each length
+	var counts, next [maxCodeLength + 1]int
+	for _, l := range code.lengths {
+		if l > 0 {
+			counts[l]++
+		}
+	}
+	for l, c := 1, 0; l <= maxCodeLength; l++ {
+		c = (c + counts[l-1]) << 1
+		next[l] = c
+	}
+	for symbol, l := range code.lengths {
+		if l > 0 {
+			code.codes[symbol] = uint16(next[l])
+			next[l]++
+		}
+	}
+	return code
+}
+
+// huffmanLengths stores the Huffman code lengths of histogram in lengths,
+// counting every used symbol at least floor times, and returns the longest
+func huffmanLengths(histogram []int, floor int, lengths []uint8) int {
+	type node struct {
+		weight      int
+		left, right int // Children, or -1 for leaves
+		symbol      int
+	}
+	var nodes []node
+	for symbol, count := range histogram {
+		lengths[symbol] = 0
+		if count > 0 {
+			nodes = append(nodes, node{weight: max(count, floor), left: -1, right: -1, symbol: symbol})
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+
+	// Two queues: leaves in weight order and merged nodes, which are created
+	// in weight order
+	leaves := len(nodes)
+	nextLeaf, nextMerged := 0, leaves
+	pop := func() int {
+		if nextLeaf < leaves && (nextMerged == len(nodes) || nodes[nextLeaf].weight <= nodes[nextMerged].weight) {
+			nextLeaf++
+			return nextLeaf - 1
+		}
+		nextMerged++
+		return nextMerged - 1
+	}
+	for i := 1; i < leaves; i++ {
+		a, b := pop(), pop()
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, left: a, right: b})
+	}
+
+	longest := 0
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if nodes[i].left < 0 {
+			lengths[nodes[i].symbol] = uint8(min(depth, 255))
+			longest = max(longest, depth)
+			return
+		}
+		walk(nodes[i].left, depth+1)
+		walk(nodes[i].right, depth+1)
+	}
+	walk(len(nodes)-1, 0)
+	return longest
+}
+
+// bitWriter packs values least significant bit first
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (b *bitWriter) write(value uint32, n uint) {
+	b.acc |= uint64(value) << b.nBits
+	b.nBits += n
+	for b.nBits >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.nBits -= 8
+	}
+}
+
+func (b *bitWriter) writeBool(v bool) {
+	if v {
+		b.write(1, 1)
+	} else {
+		b.write(0, 1)
+	}
+}
+
+func (b *bitWriter) bytes() []byte {
+	if b.nBits > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.nBits = 0, 0
+	}
+	return b.buf
+}
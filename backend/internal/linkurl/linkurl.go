@@ -0,0 +1,232 @@
+// Package linkurl validates and normalises the destination URLs of links.
+package linkurl
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// MaxLength matches the links.url column
+const MaxLength = 500
+
+// Error describes why a URL was rejected. Rule is a stable, machine-readable
+// identifier; Message is meant for humans.
+type Error struct {
+	Rule    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrRequired     = &Error{Rule: "required", Message: "URL is required"}
+	ErrTooLong      = &Error{Rule: "max", Message: "URL must be at most 500 characters"}
+	ErrInvalid      = &Error{Rule: "url", Message: "URL is not valid"}
+	ErrUnsafeScheme = &Error{Rule: "unsafe_scheme", Message: "URL scheme is not allowed"}
+	ErrScheme       = &Error{Rule: "scheme", Message: "URL scheme is not supported"}
+	ErrHost         = &Error{Rule: "host", Message: "URL must have a valid domain name"}
+	ErrCredentials  = &Error{Rule: "credentials", Message: "URL must not contain a username or password"}
+	ErrEmail        = &Error{Rule: "email", Message: "mailto: link must contain a valid email address"}
+	ErrPhone        = &Error{Rule: "phone", Message: "tel: link must contain a valid phone number"}
+)
+
+// Schemes that can execute code or embed content and are never allowed
+var blockedSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"data":       true,
+	"file":       true,
+	"blob":       true,
+	"about":      true,
+	"filesystem": true,
+}
+
+// App deep-link schemes accepted in addition to web, mail and phone links
+var deepLinkSchemes = map[string]bool{
+	"whatsapp":     true,
+	"tg":           true,
+	"spotify":      true,
+	"instagram":    true,
+	"fb":           true,
+	"fb-messenger": true,
+	"twitter":      true,
+	"youtube":      true,
+	"vnd.youtube":  true,
+	"discord":      true,
+	"snapchat":     true,
+	"tiktok":       true,
+	"linkedin":     true,
+	"zoomus":       true,
+	"skype":        true,
+	"viber":        true,
+	"line":         true,
+	"sms":          true,
+	"itms-apps":    true, // Apple App Store
+	"market":       true, // Google Play
+}
+
+var (
+	schemePattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*):`)
+	phonePattern  = regexp.MustCompile(`^\+?[0-9().-]{3,32}$`)
+)
+
+// Normalize validates raw and returns its canonical form: scheme and host are
+// lowercased, internationalised domain names are converted to punycode and
+// bare domains get an https:// scheme. A non-nil error is always an *Error.
+func Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrRequired
+	}
+	if len(raw) > MaxLength {
+		return "", ErrTooLong
+	}
+	// Control characters are used to smuggle schemes past naive filters
+	// (e.g. "java\tscript:")
+	for _, r := range raw {
+		if unicode.IsControl(r) {
+			return "", ErrInvalid
+		}
+	}
+
+	scheme := detectScheme(raw)
+	if scheme == "" {
+		raw = "https://" + raw
+		scheme = "https"
+	}
+
+	// Phone numbers are commonly written with spaces; nothing else may contain any
+	if scheme == "tel" {
+		raw = strings.ReplaceAll(raw, " ", "")
+	}
+	if strings.IndexFunc(raw, unicode.IsSpace) >= 0 {
+		return "", ErrInvalid
+	}
+
+	if blockedSchemes[scheme] {
+		return "", ErrUnsafeScheme
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	u.Scheme = scheme
+
+	var normalized string
+	switch {
+	case scheme == "http" || scheme == "https":
+		normalized, err = normalizeWeb(u)
+	case scheme == "mailto":
+		normalized, err = normalizeMailto(u)
+	case scheme == "tel":
+		normalized, err = normalizeTel(u)
+	case deepLinkSchemes[scheme]:
+		if u.Opaque == "" && u.Host == "" && u.Path == "" {
+			return "", ErrInvalid
+		}
+		normalized = u.String()
+	default:
+		return "", ErrScheme
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(normalized) > MaxLength {
+		return "", ErrTooLong
+	}
+	return normalized, nil
+}
+
+// detectScheme returns the lowercased scheme of raw, or "" when raw looks like
+// a bare domain ("example.com/page", "example.com:8080").
+func detectScheme(raw string) string {
+	m := schemePattern.FindStringSubmatch(raw)
+	if m == nil {
+		return ""
+	}
+	scheme := strings.ToLower(m[1])
+	rest := raw[len(m[0]):]
+
+	if strings.HasPrefix(rest, "//") || blockedSchemes[scheme] || deepLinkSchemes[scheme] ||
+		scheme == "mailto" || scheme == "tel" || scheme == "http" || scheme == "https" {
+		return scheme
+	}
+	// "example.com:8080/path" is a host and port, not a scheme
+	if strings.Contains(scheme, ".") || (rest != "" && rest[0] >= '0' && rest[0] <= '9') {
+		return ""
+	}
+	return scheme
+}
+
+func normalizeWeb(u *url.URL) (string, error) {
+	if u.User != nil {
+		return "", ErrCredentials
+	}
+	if u.Opaque != "" {
+		return "", ErrInvalid
+	}
+
+	hostname := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if hostname == "" {
+		return "", ErrHost
+	}
+
+	if net.ParseIP(hostname) == nil {
+		ascii, err := idna.Lookup.ToASCII(hostname)
+		if err != nil || !strings.Contains(ascii, ".") {
+			return "", ErrHost
+		}
+		hostname = ascii
+	} else if strings.Contains(hostname, ":") {
+		hostname = "[" + hostname + "]"
+	}
+
+	if port := u.Port(); port != "" {
+		u.Host = hostname + ":" + port
+	} else {
+		u.Host = hostname
+	}
+	return u.String(), nil
+}
+
+func normalizeMailto(u *url.URL) (string, error) {
+	address := u.Opaque
+	if address == "" {
+		address = u.Path
+	}
+	address, err := url.PathUnescape(address)
+	if err != nil {
+		return "", ErrEmail
+	}
+	for _, part := range strings.Split(address, ",") {
+		if _, err := mail.ParseAddress(part); err != nil {
+			return "", ErrEmail
+		}
+	}
+	return u.String(), nil
+}
+
+func normalizeTel(u *url.URL) (string, error) {
+	number := u.Opaque
+	if number == "" {
+		number = u.Path
+	}
+	number, err := url.PathUnescape(number)
+	if err != nil {
+		return "", ErrPhone
+	}
+	if !phonePattern.MatchString(number) {
+		return "", ErrPhone
+	}
+	return "tel:" + number, nil
+}
@@ -4,18 +4,43 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// AdminAuth middleware checks if user is an admin
-func AdminAuth() fiber.Handler {
+// RequirePermission middleware checks if the user has an admin permission,
+// either as an admin or through their role (use after JWTAuth middleware)
+func RequirePermission(permission string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		isAdmin := c.Locals("is_admin")
-		
-		if isAdmin == nil || !isAdmin.(bool) {
+		if !HasPermission(c, permission) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success": false,
-				"message": "Admin access required",
+				"message": "Permission required: " + permission,
 			})
 		}
-		
+
 		return c.Next()
 	}
 }
+
+// HasPermission reports whether the user has an admin permission
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	return hasPermission(GetPermissions(c), permission)
+}
+
+func hasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPermissions extracts the user's admin permissions from context
+func GetPermissions(c *fiber.Ctx) []string {
+	permissions, _ := c.Locals("permissions").([]string)
+	return permissions
+}
+
+// IsAdmin reports whether the user is an admin, who has every permission
+func IsAdmin(c *fiber.Ctx) bool {
+	isAdmin, _ := c.Locals("is_admin").(bool)
+	return isAdmin
+}
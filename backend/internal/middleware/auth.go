@@ -1,14 +1,18 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTAuth middleware for protected routes
-func JWTAuth(secret string) fiber.Handler {
+// JWTAuth middleware for protected routes. Tokens of disabled users and
+// tokens issued before the user's token version changed are rejected.
+func JWTAuth(secret string, tokens *TokenChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get token from header
 		authHeader := c.Get("Authorization")
@@ -64,20 +68,96 @@ func JWTAuth(secret string) fiber.Handler {
 			})
 		}
 
+		// Tokens issued before token versions existed count as version 0
+		version, _ := claims["tv"].(float64)
+		state, err := tokens.Check(c.Context(), int(userID), int(version))
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Database error",
+			})
+		}
+		if state == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "unauthorized",
+				"message": "Token has been revoked",
+			})
+		}
+
 		c.Locals("userID", int(userID))
 		c.Locals("username", claims["username"])
 		
-		// Store is_admin if present
-		if isAdmin, ok := claims["is_admin"].(bool); ok {
-			c.Locals("is_admin", isAdmin)
-		} else {
+		// Admin rights come from the current user state rather than the
+		// token, so they can be taken away at once
+		c.Locals("is_admin", state.IsAdmin)
+		c.Locals("permissions", state.Permissions)
+
+		// Impersonation tokens act for a staff member, who must still be
+		// allowed to impersonate. They never carry the user's admin rights
+		// and are read-only unless issued for writing.
+		if act, ok := claims["act"].(map[string]interface{}); ok {
+			impersonation, err := checkActor(c, act, tokens)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Database error",
+				})
+			}
+			if impersonation == nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error":   "unauthorized",
+					"message": "Token has been revoked",
+				})
+			}
+			if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+				impersonation.ExpiresAt = exp.Time
+			}
+
+			if impersonation.ReadOnly && c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error":   "forbidden",
+					"message": "Impersonation is read-only",
+				})
+			}
+
 			c.Locals("is_admin", false)
+			c.Locals("permissions", []string(nil))
+			c.Locals("impersonation", impersonation)
 		}
 
 		return c.Next()
 	}
 }
 
+// checkActor checks the act claim of an impersonation token. It returns nil
+// if the actor was banned, signed out everywhere or lost the permission.
+func checkActor(c *fiber.Ctx, act map[string]interface{}, tokens *TokenChecker) (*models.Impersonation, error) {
+	actorID, _ := act["user_id"].(float64)
+	version, _ := act["tv"].(float64)
+	state, err := tokens.Check(c.Context(), int(actorID), int(version))
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if state == nil || !hasPermission(state.Permissions, models.PermUsersImpersonate) {
+		return nil, nil
+	}
+
+	username, _ := act["username"].(string)
+	write, _ := act["write"].(bool)
+	return &models.Impersonation{
+		ActorID:       int(actorID),
+		ActorUsername: username,
+		ReadOnly:      !write,
+	}, nil
+}
+
+// GetImpersonation returns the impersonation the request is made under, or
+// nil for the user's own tokens
+func GetImpersonation(c *fiber.Ctx) *models.Impersonation {
+	impersonation, _ := c.Locals("impersonation").(*models.Impersonation)
+	return impersonation
+}
+
 // GetUserID extracts user ID from context (use after JWTAuth middleware)
 func GetUserID(c *fiber.Ctx) int {
 	userID, ok := c.Locals("userID").(int)
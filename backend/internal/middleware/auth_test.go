@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestJWTAuthTokenVersion(t *testing.T) {
+	users := &fakeUsers{states: map[int]models.TokenState{
+		1: {Version: 2, IsActive: true, Permissions: []string{models.PermStatsView}},
+		2: {Version: 0, IsActive: false},
+	}}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		status int
+	}{
+		{"current version", jwt.MapClaims{"user_id": 1, "tv": 2, "exp": exp}, fiber.StatusOK},
+		{"revoked version", jwt.MapClaims{"user_id": 1, "tv": 1, "exp": exp}, fiber.StatusUnauthorized},
+		{"no version", jwt.MapClaims{"user_id": 1, "exp": exp}, fiber.StatusUnauthorized},
+		{"banned user", jwt.MapClaims{"user_id": 2, "tv": 0, "exp": exp}, fiber.StatusUnauthorized},
+		{"deleted user", jwt.MapClaims{"user_id": 3, "tv": 0, "exp": exp}, fiber.StatusUnauthorized},
+		{"expired", jwt.MapClaims{"user_id": 1, "tv": 2, "exp": time.Now().Add(-time.Minute).Unix()}, fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", JWTAuth(testSecret, NewTokenChecker(users.load, time.Minute)), func(c *fiber.Ctx) error {
+				if !HasPermission(c, models.PermStatsView) || IsAdmin(c) {
+					t.Errorf("permissions = %v, admin %v", GetPermissions(c), IsAdmin(c))
+				}
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+signToken(t, tt.claims))
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+)
+
+// TokenStateFunc loads the token state of a user
+type TokenStateFunc func(ctx context.Context, userID int) (*models.TokenState, error)
+
+// TokenChecker decides whether access tokens are still valid: their user
+// must be active and the token version in the token must be current. User
+// states are cached briefly, so a ban or demotion made on another instance
+// takes effect within the cache lifetime; Forget drops a user at once.
+type TokenChecker struct {
+	load TokenStateFunc
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[int]tokenEntry
+}
+
+// Users whose state is cached before expired entries are dropped
+const maxTokenEntries = 10000
+
+type tokenEntry struct {
+	state   *models.TokenState
+	expires time.Time
+}
+
+// NewTokenChecker creates a checker caching user states for ttl
+func NewTokenChecker(load TokenStateFunc, ttl time.Duration) *TokenChecker {
+	return &TokenChecker{load: load, ttl: ttl, entries: map[int]tokenEntry{}}
+}
+
+// Check returns the state of the user a token with the given version was
+// issued to, or nil if the token is no longer valid
+func (t *TokenChecker) Check(ctx context.Context, userID, version int) (*models.TokenState, error) {
+	state, ok := t.cached(userID)
+	// A newer version than cached means the state changed since it was
+	// cached, e.g. a new token after a password change
+	if !ok || version > state.Version {
+		var err error
+		if state, err = t.load(ctx, userID); err != nil {
+			return nil, err
+		}
+		t.store(userID, state)
+	}
+
+	if !state.IsActive || version != state.Version {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// Forget drops the cached state of a user, for changes that invalidate
+// their tokens
+func (t *TokenChecker) Forget(userID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, userID)
+}
+
+func (t *TokenChecker) cached(userID int) (*models.TokenState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[userID]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.state, true
+}
+
+func (t *TokenChecker) store(userID int, state *models.TokenState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.entries) >= maxTokenEntries {
+		now := time.Now()
+		for id, e := range t.entries {
+			if now.After(e.expires) {
+				delete(t.entries, id)
+			}
+		}
+	}
+	t.entries[userID] = tokenEntry{state: state, expires: time.Now().Add(t.ttl)}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+)
+
+// fakeUsers serves token states and counts how often they are loaded
+type fakeUsers struct {
+	states map[int]models.TokenState
+	loads  int
+}
+
+func (f *fakeUsers) load(ctx context.Context, userID int) (*models.TokenState, error) {
+	f.loads++
+	state, ok := f.states[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &state, nil
+}
+
+func TestTokenCheckerCheck(t *testing.T) {
+	users := &fakeUsers{states: map[int]models.TokenState{
+		1: {Version: 3, IsActive: true},
+		2: {Version: 0, IsActive: false},
+		3: {Version: 1, IsActive: true, IsAdmin: true, Permissions: models.AllPermissions},
+	}}
+
+	tests := []struct {
+		name    string
+		userID  int
+		version int
+		valid   bool
+		err     error
+	}{
+		{name: "current version", userID: 1, version: 3, valid: true},
+		{name: "older version", userID: 1, version: 2},
+		{name: "tokens before versions", userID: 1, version: 0},
+		{name: "newer version", userID: 1, version: 4},
+		{name: "inactive user", userID: 2, version: 0},
+		{name: "admin", userID: 3, version: 1, valid: true},
+		{name: "deleted user", userID: 4, version: 0, err: repository.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := NewTokenChecker(users.load, time.Minute)
+			state, err := tokens.Check(context.Background(), tt.userID, tt.version)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Check error = %v, want %v", err, tt.err)
+			}
+			if (state != nil) != tt.valid {
+				t.Errorf("Check = %+v, want valid %v", state, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTokenCheckerCache(t *testing.T) {
+	users := &fakeUsers{states: map[int]models.TokenState{1: {Version: 1, IsActive: true}}}
+	tokens := NewTokenChecker(users.load, time.Hour)
+	ctx := context.Background()
+
+	check := func(version int, valid bool, loads int) {
+		t.Helper()
+		state, err := tokens.Check(ctx, 1, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (state != nil) != valid {
+			t.Errorf("Check(version %d) = %+v, want valid %v", version, state, valid)
+		}
+		if users.loads != loads {
+			t.Errorf("state loaded %d times, want %d", users.loads, loads)
+		}
+	}
+
+	check(1, true, 1)
+	check(1, true, 1)
+	check(0, false, 1)
+
+	// A ban on another instance is not seen until the entry expires...
+	users.states[1] = models.TokenState{Version: 1, IsActive: false}
+	check(1, true, 1)
+	// ...or is forgotten
+	tokens.Forget(1)
+	check(1, false, 2)
+
+	// A token newer than the cached state reloads it, e.g. after a password
+	// change on another instance, and the old tokens stop working
+	users.states[1] = models.TokenState{Version: 2, IsActive: true}
+	check(2, true, 3)
+	check(1, false, 3)
+}
+
+func TestTokenCheckerExpiry(t *testing.T) {
+	users := &fakeUsers{states: map[int]models.TokenState{1: {Version: 1, IsActive: true}}}
+	tokens := NewTokenChecker(users.load, -time.Second)
+
+	for i := 1; i <= 3; i++ {
+		if state, _ := tokens.Check(context.Background(), 1, 1); state == nil {
+			t.Fatal("Check rejected a current token")
+		}
+		if users.loads != i {
+			t.Fatalf("expired state loaded %d times, want %d", users.loads, i)
+		}
+	}
+
+	// Expired entries are dropped once the cache is full
+	for id := 0; id < maxTokenEntries+1; id++ {
+		tokens.store(id, &models.TokenState{})
+	}
+	if len(tokens.entries) > 2 {
+		t.Errorf("cache holds %d entries after pruning", len(tokens.entries))
+	}
+}
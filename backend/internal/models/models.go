@@ -11,10 +11,52 @@ type User struct {
 	IsVerified   bool       `json:"is_verified"`
 	IsActive     bool       `json:"is_active"`
 	IsAdmin      bool       `json:"is_admin"`
+	IsPremium    bool       `json:"is_premium"`
+	Role         *string    `json:"role,omitempty"` // Staff role, see Perm constants
+	TokenVersion int        `json:"-"` // Access tokens carrying an older version are rejected
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
 }
 
+// TokenState is what decides whether a user's access tokens are still valid,
+// and what they allow
+type TokenState struct {
+	Version     int
+	IsActive    bool
+	IsAdmin     bool
+	Permissions []string // Of the user's role; admins have all of them
+}
+
+// Role is a set of admin permissions given to staff users
+type Role struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Admin permissions
+const (
+	PermStatsView        = "stats.view"
+	PermUsersView        = "users.view"
+	PermUsersManage      = "users.manage" // Verify, ban, premium
+	PermRolesGrant       = "roles.grant"  // Grant or take admin rights and roles
+	PermProfilesView     = "profiles.view"
+	PermProfilesModerate = "profiles.moderate"
+	PermLinksReview      = "links.review"
+	PermAuditView        = "audit.view"
+	PermReportsReview    = "reports.review"
+	PermUsersImpersonate = "users.impersonate" // Act as a user, read-only
+)
+
+// AllPermissions lists every admin permission
+var AllPermissions = []string{
+	PermStatsView, PermUsersView, PermUsersManage, PermRolesGrant,
+	PermProfilesView, PermProfilesModerate, PermLinksReview, PermAuditView,
+	PermReportsReview, PermUsersImpersonate,
+}
+
 // UserPublic is the safe version for API responses
 type UserPublic struct {
 	ID         int       `json:"id"`
@@ -22,7 +64,23 @@ type UserPublic struct {
 	Email      string    `json:"email"`
 	IsVerified bool      `json:"is_verified"`
 	IsAdmin    bool      `json:"is_admin"`
+	IsPremium  bool      `json:"is_premium"`
+	Role       *string   `json:"role,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
+
+	// Admin permissions, only filled in for the current user
+	Permissions []string `json:"permissions,omitempty"`
+
+	// Set when the current user is being impersonated, to show a banner
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
+}
+
+// Impersonation describes an access token issued to staff to act as a user
+type Impersonation struct {
+	ActorID       int       `json:"actor_id"`
+	ActorUsername string    `json:"actor_username"`
+	ReadOnly      bool      `json:"read_only"`
+	ExpiresAt     time.Time `json:"expires_at"`
 }
 
 // ToPublic converts User to UserPublic
@@ -33,6 +91,8 @@ func (u *User) ToPublic() UserPublic {
 		Email:      u.Email,
 		IsVerified: u.IsVerified,
 		IsAdmin:    u.IsAdmin,
+		IsPremium:  u.IsPremium,
+		Role:       u.Role,
 		CreatedAt:  u.CreatedAt,
 	}
 }
@@ -47,11 +107,26 @@ type Profile struct {
 	Bio          *string    `json:"bio,omitempty"`
 	Avatar       string     `json:"avatar"`
 	IsActive     bool       `json:"is_active"`
+	Visibility   string     `json:"visibility"`
+	PasswordHash *string    `json:"-"` // Only set for password-protected profiles
 	DisplayOrder int        `json:"display_order"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
 }
 
+// Profile visibility modes
+const (
+	VisibilityPublic   = "public"   // Listed and indexable
+	VisibilityUnlisted = "unlisted" // Reachable by slug, never listed or indexed
+	VisibilityPassword = "password" // Requires unlocking with the profile password
+)
+
+// IsIndexable reports whether the profile may appear in directories,
+// sitemaps and search engine results
+func (p *Profile) IsIndexable() bool {
+	return p.Visibility == VisibilityPublic
+}
+
 // ProfileWithStats includes link count and click stats
 type ProfileWithStats struct {
 	Profile
@@ -63,6 +138,14 @@ type ProfileWithStats struct {
 type Theme struct {
 	ID                 int        `json:"id"`
 	ProfileID          int        `json:"profile_id"`
+	ThemeSettings
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
+}
+
+// ThemeSettings holds the appearance fields of a theme. Presets store them
+// as JSON.
+type ThemeSettings struct {
 	BgType             string     `json:"bg_type"`
 	BgValue            *string    `json:"bg_value,omitempty"`
 	ButtonStyle        string     `json:"button_style"`
@@ -81,8 +164,40 @@ type Theme struct {
 	BoxedMaxWidth      int        `json:"boxed_max_width"`
 	BoxedRadius        int        `json:"boxed_radius"`
 	BoxedShadow        bool       `json:"boxed_shadow"`
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
+	CustomCSS          *string    `json:"custom_css,omitempty"`      // Premium: sanitized, scoped CSS
+	CustomFontURL      *string    `json:"custom_font_url,omitempty"` // Premium: uploaded font file
+}
+
+// ThemeVersion is a snapshot of a theme after one change
+type ThemeVersion struct {
+	ID        int64         `json:"id"`
+	ProfileID int           `json:"profile_id"`
+	Version   int           `json:"version"`
+	Settings  ThemeSettings `json:"settings"`
+	Source    string        `json:"source"` // What made the change, one of the ThemeSource constants
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Sources of theme changes
+const (
+	ThemeSourceCreate  = "create"
+	ThemeSourceUpdate  = "update"
+	ThemeSourcePreset  = "preset"
+	ThemeSourceUpload  = "upload"
+	ThemeSourceRestore = "restore"
+)
+
+// ThemePreset is a named set of theme settings: built in (no user) or saved
+// by a user for their profiles
+type ThemePreset struct {
+	ID           int           `json:"id"`
+	UserID       *int          `json:"user_id,omitempty"`
+	Slug         *string       `json:"slug,omitempty"`
+	Name         string        `json:"name"`
+	Settings     ThemeSettings `json:"settings"`
+	IsDefault    bool          `json:"is_default"`
+	DisplayOrder int           `json:"display_order"`
+	CreatedAt    time.Time     `json:"created_at"`
 }
 
 // Category represents a link category/folder
@@ -108,22 +223,106 @@ type Link struct {
 	Position   int        `json:"position"`
 	Clicks     int        `json:"clicks"`
 	IsActive   bool       `json:"is_active"`
+	SplitMode  string     `json:"split_mode"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+
+	ScreeningStatus  string     `json:"screening_status"`
+	ScreeningReasons []string   `json:"screening_reasons,omitempty"`
+	ScreenedAt       *time.Time `json:"screened_at,omitempty"`
+}
+
+// Screening statuses of a link's destination
+const (
+	ScreeningPending  = "pending"  // Not screened yet
+	ScreeningClean    = "clean"    // Passed the blocklist and heuristics
+	ScreeningFlagged  = "flagged"  // Suspicious; deactivated until an admin reviews it
+	ScreeningBlocked  = "blocked"  // Blocklisted or rejected by an admin
+	ScreeningApproved = "approved" // Cleared by an admin; heuristics no longer apply
+)
+
+// UnderReview reports whether the link is held back by URL screening and
+// must stay deactivated
+func (l *Link) UnderReview() bool {
+	return l.ScreeningStatus == ScreeningFlagged || l.ScreeningStatus == ScreeningBlocked
 }
 
+// Split modes for links with destination variants
+const (
+	SplitWeighted = "weighted" // Random weighted pick on every click
+	SplitSticky   = "sticky"   // Same visitor always gets the same variant
+)
+
+// LinkVariant is an alternative destination for an A/B split link
+type LinkVariant struct {
+	ID        int        `json:"id"`
+	LinkID    int        `json:"link_id"`
+	Label     *string    `json:"label,omitempty"`
+	URL       string     `json:"url"`
+	Weight    int        `json:"weight"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Link rule types
+const (
+	RuleCountry  = "country"  // ISO 3166-1 alpha-2 country codes
+	RuleOS       = "os"       // ios, android, windows, macos, linux
+	RuleLanguage = "language" // BCP 47 language tags from Accept-Language
+)
+
+// LinkRule sends matching visitors to an alternative URL. Visitors that match
+// no rule fall through to the link's default URL.
+type LinkRule struct {
+	ID          int        `json:"id"`
+	LinkID      int        `json:"link_id"`
+	RuleType    string     `json:"rule_type"`
+	MatchValues []string   `json:"match_values"`
+	URL         string     `json:"url"`
+	Priority    int        `json:"priority"`
+	IsActive    bool       `json:"is_active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// Where a click was sent
+const (
+	ClickTargetLink    = "link"    // The link's own URL
+	ClickTargetVariant = "variant" // An A/B split variant
+	ClickTargetRule    = "rule"    // A targeting rule
+)
+
 // Click represents a link click event
 type Click struct {
 	ID        int64     `json:"id"`
 	LinkID    int       `json:"link_id"`
+	VariantID *int      `json:"variant_id,omitempty"`
+	RuleID    *int      `json:"rule_id,omitempty"`
+	Target    string    `json:"target"` // ClickTargetLink, ClickTargetVariant or ClickTargetRule
 	IP        *string   `json:"ip,omitempty"`
 	Country   *string   `json:"country,omitempty"`
 	City      *string   `json:"city,omitempty"`
 	UserAgent *string   `json:"user_agent,omitempty"`
 	Referrer  *string   `json:"referrer,omitempty"`
+	Source    *string   `json:"source,omitempty"` // e.g. "qr"; nil for direct visits
 	ClickedAt time.Time `json:"clicked_at"`
 }
 
+// ProfileView represents a view of a public profile page
+type ProfileView struct {
+	ID        int64     `json:"id"`
+	ProfileID int       `json:"profile_id"`
+	Source    *string   `json:"source,omitempty"`
+	Country   *string   `json:"country,omitempty"`
+	UserAgent *string   `json:"user_agent,omitempty"`
+	Referrer  *string   `json:"referrer,omitempty"`
+	ViewedAt  time.Time `json:"viewed_at"`
+}
+
+// Traffic source of visits through a scanned QR code
+const SourceQR = "qr"
+
 // Session represents a user session
 type Session struct {
 	ID           string    `json:"id"`
@@ -143,3 +342,155 @@ type SocialIcon struct {
 	IconColor    *string `json:"icon_color,omitempty"`
 	BaseURL      *string `json:"base_url,omitempty"`
 }
+
+// Asset represents an uploaded image or font kept in asset storage
+type Asset struct {
+	ID          int64     `json:"id"`
+	UserID      *int      `json:"user_id,omitempty"`
+	ProfileID   *int      `json:"profile_id,omitempty"` // NULL once the profile is deleted
+	Kind        string    `json:"kind"`
+	StorageKey  string    `json:"storage_key"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int       `json:"size_bytes"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Asset kinds
+const (
+	AssetAvatar     = "avatar"
+	AssetBackground = "background"
+	AssetFont       = "font"
+)
+
+// ProfilePublication is a published snapshot of a profile. The live one is
+// what visitors see; older ones are kept for rollback.
+type ProfilePublication struct {
+	ID          int64            `json:"id"`
+	ProfileID   int              `json:"profile_id"`
+	Content     PublishedContent `json:"content"`
+	IsLive      bool             `json:"is_live"`
+	PublishedAt time.Time        `json:"published_at"`
+}
+
+// PublishedContent is the part of a profile that is edited as a draft.
+// Slug, visibility and password are settings and always apply at once.
+type PublishedContent struct {
+	Name       string     `json:"name"`
+	Title      *string    `json:"title,omitempty"`
+	Bio        *string    `json:"bio,omitempty"`
+	Avatar     string     `json:"avatar"`
+	Theme      Theme      `json:"theme"`
+	Categories []Category `json:"categories"`
+	Links      []Link     `json:"links"` // Active links only
+}
+
+// Apply returns the profile with its published name, title, bio and avatar
+func (c *PublishedContent) Apply(p Profile) Profile {
+	p.Name = c.Name
+	p.Title = c.Title
+	p.Bio = c.Bio
+	p.Avatar = c.Avatar
+	return p
+}
+
+// AuditEvent records one change to an account, profile or link
+type AuditEvent struct {
+	ID            int64                  `json:"id"`
+	ActorID       *int                   `json:"actor_id"` // nil once the actor is deleted
+	ActorUsername *string                `json:"actor_username,omitempty"`
+	TargetUserID  *int                   `json:"target_user_id"`
+	Action        string                 `json:"action"`
+	TargetType    string                 `json:"target_type"`
+	TargetID      int                    `json:"target_id"`
+	Changes       map[string]AuditChange `json:"changes"`
+	IP            *string                `json:"ip,omitempty"`
+	UserAgent     *string                `json:"user_agent,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// ActivityEvent is an audit event as shown to the owner of the changed data.
+// Where changes made by someone else came from is not shown.
+type ActivityEvent struct {
+	ID         int64                  `json:"id"`
+	Action     string                 `json:"action"`
+	Actor      string                 `json:"actor"` // "you" or "admin"
+	TargetType string                 `json:"target_type"`
+	TargetID   int                    `json:"target_id"`
+	Changes    map[string]AuditChange `json:"changes"`
+	IP         *string                `json:"ip,omitempty"`
+	UserAgent  *string                `json:"user_agent,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditChange is the value of a field before and after a change
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Audited actions
+const (
+	AuditLogin           = "auth.login"
+	AuditUserUpdate      = "user.update"
+	AuditPasswordChange  = "user.password"
+	AuditProfileCreate   = "profile.create"
+	AuditProfileUpdate   = "profile.update"
+	AuditProfileDelete   = "profile.delete"
+	AuditProfilePublish  = "profile.publish"
+	AuditProfileRollback = "profile.rollback"
+	AuditLinkReview      = "link.review"
+	AuditReportResolve   = "report.resolve"
+	AuditImpersonate     = "user.impersonate"
+	AuditImpersonated    = "impersonation.request" // A request made with an impersonation token
+)
+
+// Types of audited objects
+const (
+	AuditTargetUser    = "user"
+	AuditTargetProfile = "profile"
+	AuditTargetLink    = "link"
+	AuditTargetReport  = "report"
+)
+
+// Report is an abuse report about a profile or link
+type Report struct {
+	ID             int64      `json:"id"`
+	TargetType     string     `json:"target_type"` // "profile" or "link"
+	TargetID       int        `json:"target_id"`
+	ProfileID      *int       `json:"profile_id"` // nil once the profile is deleted
+	ProfileSlug    *string    `json:"profile_slug,omitempty"`
+	Reason         string     `json:"reason"`
+	Details        *string    `json:"details,omitempty"`
+	ReporterEmail  *string    `json:"reporter_email,omitempty"`
+	ReporterIP     *string    `json:"reporter_ip,omitempty"`
+	Status         string     `json:"status"`
+	Action         string     `json:"action"`
+	ResolutionNote *string    `json:"resolution_note,omitempty"`
+	ResolvedBy     *int       `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Report targets
+const (
+	ReportTargetProfile = "profile"
+	ReportTargetLink    = "link"
+)
+
+// Report statuses
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// Actions taken when resolving a report
+const (
+	ReportActionNone        = "none"
+	ReportActionHideProfile = "hide_profile"
+	ReportActionDisableLink = "disable_link"
+	ReportActionBanUser     = "ban_user"
+)
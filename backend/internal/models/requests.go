@@ -3,8 +3,8 @@ package models
 // RegisterRequest for user registration
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
 
 // LoginRequest for user login
@@ -13,6 +13,46 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// SendOTPRequest starts the OTP registration flow
+type SendOTPRequest struct {
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// VerifyOTPRequest checks an OTP code
+type VerifyOTPRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	OTP   string `json:"otp" validate:"required,len=6,numeric"`
+}
+
+// CompleteRegistrationRequest finishes the OTP registration flow
+type CompleteRegistrationRequest struct {
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	OTP      string `json:"otp" validate:"required,len=6,numeric"`
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Slug     string `json:"slug" validate:"omitempty,slug"`
+}
+
+// UpdateCurrentUserRequest for updating the current user
+type UpdateCurrentUserRequest struct {
+	Username *string `json:"username" validate:"omitnil,min=3,max=50"`
+	Email    *string `json:"email" validate:"omitnil,email,max=100"`
+}
+
+// ChangePasswordRequest for changing the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
 // AuthResponse returned after successful auth
 type AuthResponse struct {
 	User         UserPublic `json:"user"`
@@ -28,100 +68,218 @@ type RefreshRequest struct {
 
 // CreateProfileRequest for creating a new profile
 type CreateProfileRequest struct {
-	Slug  string  `json:"slug" validate:"required,min=3,max=50,alphanum"`
+	Slug  string  `json:"slug" validate:"required,slug"`
 	Name  string  `json:"name" validate:"required,max=100"`
-	Title *string `json:"title,omitempty"`
-	Bio   *string `json:"bio,omitempty"`
+	Title *string `json:"title,omitempty" validate:"omitnil,max=100"`
+	Bio   *string `json:"bio,omitempty" validate:"omitnil,max=1000"`
 }
 
 // UpdateProfileRequest for updating a profile
 type UpdateProfileRequest struct {
-	Slug     *string `json:"slug,omitempty"`
-	Name     *string `json:"name,omitempty"`
-	Title    *string `json:"title,omitempty"`
-	Bio      *string `json:"bio,omitempty"`
-	Avatar   *string `json:"avatar,omitempty"`
-	IsActive *bool   `json:"is_active,omitempty"`
+	Slug       *string `json:"slug,omitempty" validate:"omitnil,slug"`
+	Name       *string `json:"name,omitempty" validate:"omitnil,min=1,max=100"`
+	Title      *string `json:"title,omitempty" validate:"omitnil,max=100"`
+	Bio        *string `json:"bio,omitempty" validate:"omitnil,max=1000"`
+	Avatar     *string `json:"avatar,omitempty" validate:"omitnil,max=255"`
+	IsActive   *bool   `json:"is_active,omitempty"`
+	Visibility *string `json:"visibility,omitempty" validate:"omitnil,oneof=public unlisted password"`
+	Password   *string `json:"password,omitempty" validate:"omitnil,min=8,max=72"` // Required when switching to password visibility
+}
+
+// UnlockProfileRequest for unlocking a password-protected profile
+type UnlockProfileRequest struct {
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// SlugAvailabilityResponse tells whether a slug can be claimed
+type SlugAvailabilityResponse struct {
+	Slug        string   `json:"slug"`
+	Available   bool     `json:"available"`
+	Rule        string   `json:"rule,omitempty"`    // Why the slug is unavailable
+	Message     string   `json:"message,omitempty"`
+	Suggestions []string `json:"suggestions"`
 }
 
 // CreateLinkRequest for creating a new link
 type CreateLinkRequest struct {
 	Title      string `json:"title" validate:"required,max=100"`
-	URL        string `json:"url" validate:"required,url,max=500"`
-	Icon       string `json:"icon,omitempty"`
-	CategoryID *int   `json:"category_id,omitempty"`
-	Position   *int   `json:"position,omitempty"`
+	URL        string `json:"url" validate:"required,linkurl"`
+	Icon       string `json:"icon,omitempty" validate:"omitempty,max=50"`
+	CategoryID *int   `json:"category_id,omitempty" validate:"omitnil,min=1"`
+	Position   *int   `json:"position,omitempty" validate:"omitnil,min=0"`
 }
 
 // UpdateLinkRequest for updating a link
 type UpdateLinkRequest struct {
-	Title      *string `json:"title,omitempty"`
-	URL        *string `json:"url,omitempty"`
-	Icon       *string `json:"icon,omitempty"`
-	CategoryID *int    `json:"category_id,omitempty"`
-	Position   *int    `json:"position,omitempty"`
+	Title      *string `json:"title,omitempty" validate:"omitnil,min=1,max=100"`
+	URL        *string `json:"url,omitempty" validate:"omitnil,linkurl"`
+	Icon       *string `json:"icon,omitempty" validate:"omitnil,max=50"`
+	CategoryID *int    `json:"category_id,omitempty" validate:"omitnil,min=1"`
+	Position   *int    `json:"position,omitempty" validate:"omitnil,min=0"`
 	IsActive   *bool   `json:"is_active,omitempty"`
+	SplitMode  *string `json:"split_mode,omitempty" validate:"omitnil,oneof=weighted sticky"`
+}
+
+// CreateLinkVariantRequest for adding a destination variant to a link
+type CreateLinkVariantRequest struct {
+	Label  *string `json:"label,omitempty" validate:"omitnil,max=50"`
+	URL    string  `json:"url" validate:"required,linkurl"`
+	Weight *int    `json:"weight,omitempty" validate:"omitnil,min=0,max=1000"`
+}
+
+// UpdateLinkVariantRequest for updating a destination variant
+type UpdateLinkVariantRequest struct {
+	Label    *string `json:"label,omitempty" validate:"omitnil,max=50"`
+	URL      *string `json:"url,omitempty" validate:"omitnil,linkurl"`
+	Weight   *int    `json:"weight,omitempty" validate:"omitnil,min=0,max=1000"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// CreateLinkRuleRequest for adding a targeting rule to a link
+type CreateLinkRuleRequest struct {
+	RuleType    string   `json:"rule_type" validate:"required,oneof=country os language"`
+	MatchValues []string `json:"match_values" validate:"required,min=1,max=50,dive,required,max=35"`
+	URL         string   `json:"url" validate:"required,linkurl"`
+	Priority    *int     `json:"priority,omitempty"`
+}
+
+// UpdateLinkRuleRequest for updating a targeting rule
+type UpdateLinkRuleRequest struct {
+	MatchValues []string `json:"match_values,omitempty" validate:"omitempty,max=50,dive,required,max=35"`
+	URL         *string  `json:"url,omitempty" validate:"omitnil,linkurl"`
+	Priority    *int     `json:"priority,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
 }
 
 // ReorderLinksRequest for reordering links
 type ReorderLinksRequest struct {
-	Links []LinkPosition `json:"links" validate:"required"`
+	Links []LinkPosition `json:"links" validate:"required,min=1,dive"`
 }
 
 // LinkPosition for reordering
 type LinkPosition struct {
-	ID       int `json:"id"`
-	Position int `json:"position"`
+	ID       int `json:"id" validate:"required,min=1"`
+	Position int `json:"position" validate:"min=0"`
 }
 
 // CreateCategoryRequest for creating a category
 type CreateCategoryRequest struct {
 	Name     string `json:"name" validate:"required,max=100"`
-	Icon     string `json:"icon,omitempty"`
-	Color    string `json:"color,omitempty"`
-	Position *int   `json:"position,omitempty"`
+	Icon     string `json:"icon,omitempty" validate:"omitempty,max=50"`
+	Color    string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+	Position *int   `json:"position,omitempty" validate:"omitnil,min=0"`
 }
 
 // UpdateCategoryRequest for updating a category
 type UpdateCategoryRequest struct {
-	Name       *string `json:"name,omitempty"`
-	Icon       *string `json:"icon,omitempty"`
-	Color      *string `json:"color,omitempty"`
-	Position   *int    `json:"position,omitempty"`
+	Name       *string `json:"name,omitempty" validate:"omitnil,min=1,max=100"`
+	Icon       *string `json:"icon,omitempty" validate:"omitnil,max=50"`
+	Color      *string `json:"color,omitempty" validate:"omitnil,hexcolor"`
+	Position   *int    `json:"position,omitempty" validate:"omitnil,min=0"`
 	IsExpanded *bool   `json:"is_expanded,omitempty"`
 }
 
 // UpdateThemeRequest for updating theme
 type UpdateThemeRequest struct {
-	BgType             *string `json:"bg_type,omitempty"`
-	BgValue            *string `json:"bg_value,omitempty"`
-	ButtonStyle        *string `json:"button_style,omitempty"`
-	ButtonColor        *string `json:"button_color,omitempty"`
-	TextColor          *string `json:"text_color,omitempty"`
-	Font               *string `json:"font,omitempty"`
-	Layout             *string `json:"layout,omitempty"`
-	ContainerStyle     *string `json:"container_style,omitempty"`
-	EnableAnimations   *bool   `json:"enable_animations,omitempty"`
-	EnableGlassEffect  *bool   `json:"enable_glass_effect,omitempty"`
-	ShadowIntensity    *string `json:"shadow_intensity,omitempty"`
-	BoxedEnabled       *bool   `json:"boxed_enabled,omitempty"`
-	BoxedOuterBgType   *string `json:"boxed_outer_bg_type,omitempty"`
-	BoxedOuterBgValue  *string `json:"boxed_outer_bg_value,omitempty"`
-	BoxedContainerBg   *string `json:"boxed_container_bg,omitempty"`
-	BoxedMaxWidth      *int    `json:"boxed_max_width,omitempty"`
-	BoxedRadius        *int    `json:"boxed_radius,omitempty"`
-	BoxedShadow        *bool   `json:"boxed_shadow,omitempty"`
+	BgType            *string `json:"bg_type,omitempty" validate:"omitnil,max=20"`
+	BgValue           *string `json:"bg_value,omitempty" validate:"omitnil,max=1000"`
+	ButtonStyle       *string `json:"button_style,omitempty" validate:"omitnil,max=20"`
+	ButtonColor       *string `json:"button_color,omitempty" validate:"omitnil,max=20"`
+	TextColor         *string `json:"text_color,omitempty" validate:"omitnil,max=20"`
+	Font              *string `json:"font,omitempty" validate:"omitnil,max=50"`
+	Layout            *string `json:"layout,omitempty" validate:"omitnil,max=20"`
+	ContainerStyle    *string `json:"container_style,omitempty" validate:"omitnil,max=20"`
+	EnableAnimations  *bool   `json:"enable_animations,omitempty"`
+	EnableGlassEffect *bool   `json:"enable_glass_effect,omitempty"`
+	ShadowIntensity   *string `json:"shadow_intensity,omitempty" validate:"omitnil,max=20"`
+	BoxedEnabled      *bool   `json:"boxed_enabled,omitempty"`
+	BoxedOuterBgType  *string `json:"boxed_outer_bg_type,omitempty" validate:"omitnil,max=20"`
+	BoxedOuterBgValue *string `json:"boxed_outer_bg_value,omitempty" validate:"omitnil,max=1000"`
+	BoxedContainerBg  *string `json:"boxed_container_bg,omitempty" validate:"omitnil,max=20"`
+	BoxedMaxWidth     *int    `json:"boxed_max_width,omitempty" validate:"omitnil,min=0"`
+	BoxedRadius       *int    `json:"boxed_radius,omitempty" validate:"omitnil,min=0"`
+	BoxedShadow       *bool   `json:"boxed_shadow,omitempty"`
+	CustomCSS         *string `json:"custom_css,omitempty" validate:"omitnil,max=10000"` // Premium; "" removes it
+}
+
+// ApplyThemePresetRequest for replacing a theme with a preset
+type ApplyThemePresetRequest struct {
+	PresetID int `json:"preset_id" validate:"required,min=1"`
+}
+
+// CreateThemePresetRequest for saving a profile's theme as a personal preset
+type CreateThemePresetRequest struct {
+	Name      string `json:"name" validate:"required,min=1,max=100"`
+	ProfileID int    `json:"profile_id" validate:"required,min=1"`
 }
 
 // TrackClickRequest for tracking link clicks
 type TrackClickRequest struct {
-	Referrer *string `json:"referrer,omitempty"`
+	Referrer *string `json:"referrer,omitempty" validate:"omitnil,max=2048"`
+	Source   *string `json:"source,omitempty" validate:"omitnil,max=20,alphanum"`
+}
+
+// QRCodeRequest holds the query options of QR code downloads
+type QRCodeRequest struct {
+	Format     string `query:"format" validate:"omitempty,oneof=png svg"`
+	Size       int    `query:"size" validate:"omitempty,min=128,max=2048"`
+	Margin     *int   `query:"margin" validate:"omitnil,min=0,max=16"`
+	Level      string `query:"level" validate:"omitempty,oneof=L M Q H"`
+	Foreground string `query:"fg" validate:"omitempty,hexcolor"`
+	Background string `query:"bg" validate:"omitempty,hexcolor"`
+	Logo       bool   `query:"logo"` // Put the profile avatar in the center
+}
+
+// AdminUpdateUserRequest for changing a user's status
+type AdminUpdateUserRequest struct {
+	IsVerified *bool   `json:"is_verified"`
+	IsActive   *bool   `json:"is_active"`
+	IsAdmin    *bool   `json:"is_admin"`
+	IsPremium  *bool   `json:"is_premium"`
+	Role       *string `json:"role" validate:"omitnil,max=50"` // Staff role; "" removes it
+}
+
+// AdminUpdateProfileRequest for hiding or showing a profile
+type AdminUpdateProfileRequest struct {
+	IsActive *bool `json:"is_active"`
+}
+
+// AdminReviewLinkRequest resolves a link held by URL screening
+type AdminReviewLinkRequest struct {
+	Action string `json:"action" validate:"required,oneof=approve block"`
+}
+
+// AdminImpersonateRequest asks for a token to act as a user. Tokens are
+// read-only unless Write is set, which only admins may do.
+type AdminImpersonateRequest struct {
+	Write  bool    `json:"write"`
+	Reason *string `json:"reason" validate:"omitnil,max=500"`
+}
+
+// CreateReportRequest reports a profile or link. Website is a honeypot field
+// that people leave empty.
+type CreateReportRequest struct {
+	TargetType string  `json:"target_type" validate:"required,oneof=profile link"`
+	TargetID   int     `json:"target_id" validate:"required,min=1"`
+	Reason     string  `json:"reason" validate:"required,oneof=spam phishing malware harassment impersonation illegal other"`
+	Details    *string `json:"details" validate:"omitnil,max=2000"`
+	Email      *string `json:"email" validate:"omitnil,email,max=255"` // To be told the outcome
+	Website    string  `json:"website"`
+}
+
+// AdminResolveReportRequest closes a report, taking an action against its
+// target when resolving it
+type AdminResolveReportRequest struct {
+	Status string  `json:"status" validate:"required,oneof=resolved dismissed"`
+	Action string  `json:"action" validate:"omitempty,oneof=none hide_profile disable_link ban_user"`
+	Note   *string `json:"note" validate:"omitnil,max=2000"`
 }
 
 // PublicProfile is the response for public profile viewing
 type PublicProfile struct {
 	Profile    Profile    `json:"profile"`
 	Theme      Theme      `json:"theme"`
+	ThemeCSS   string     `json:"theme_css"` // Sanitized CSS variables of the theme
 	Categories []Category `json:"categories"`
 	Links      []Link     `json:"links"`
 	IsVerified bool       `json:"is_verified"`
@@ -134,6 +292,9 @@ type AnalyticsResponse struct {
 	ClicksByLink    []LinkStats           `json:"clicks_by_link"`
 	ClicksByCountry []CountryStats        `json:"clicks_by_country"`
 	TopReferrers    []ReferrerStats       `json:"top_referrers"`
+	ClicksBySource  []SourceStats         `json:"clicks_by_source"`
+	TotalViews      int                   `json:"total_views"`
+	ViewsBySource   []SourceStats         `json:"views_by_source"`
 }
 
 // DayStats for daily click stats
@@ -160,3 +321,39 @@ type ReferrerStats struct {
 	Referrer string `json:"referrer"`
 	Clicks   int    `json:"clicks"`
 }
+
+// SourceStats for traffic sources ("direct" when untagged)
+type SourceStats struct {
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+}
+
+// LinkAnalyticsResponse for per-link analytics
+type LinkAnalyticsResponse struct {
+	LinkID         int            `json:"link_id"`
+	Title          string         `json:"title"`
+	TotalClicks    int            `json:"total_clicks"`
+	ClicksByDay    []DayStats     `json:"clicks_by_day"`
+	ClicksBySource []SourceStats  `json:"clicks_by_source"`
+	Variants       []VariantStats `json:"variants"`
+}
+
+// Kinds of link analytics rows besides the ClickTarget* destinations
+const (
+	DestinationDeletedVariants = "deleted_variants" // Variants deleted since
+	DestinationDeletedRules    = "deleted_rules"    // Targeting rules deleted since
+	DestinationUnknown         = "unknown"          // Older clicks whose route was not recorded
+)
+
+// VariantStats for A/B split results and the clicks of every other
+// destination of a link
+type VariantStats struct {
+	Kind      string  `json:"kind"`       // A ClickTarget* or Destination* kind
+	VariantID *int    `json:"variant_id"` // Set for variants
+	RuleID    *int    `json:"rule_id,omitempty"`
+	Label     *string `json:"label,omitempty"`
+	URL       string  `json:"url"`
+	Weight    int     `json:"weight"`
+	Clicks    int     `json:"clicks"`
+	Share     float64 `json:"share"` // Percentage of clicks in the period
+}
@@ -0,0 +1,51 @@
+package ogimage
+
+import "sync"
+
+// Cache keeps the most recently rendered card of each profile in memory.
+// Entries are keyed by profile and only served while their fingerprint still
+// matches, so edits to a profile or its theme produce a fresh card.
+type Cache struct {
+	mu      sync.Mutex
+	max     int
+	entries map[int]cacheEntry
+	order   []int // Profile IDs, least recently stored first
+}
+
+type cacheEntry struct {
+	fingerprint string
+	png         []byte
+}
+
+// NewCache creates a cache holding at most max cards
+func NewCache(max int) *Cache {
+	return &Cache{max: max, entries: make(map[int]cacheEntry)}
+}
+
+// Get returns the cached card of a profile if it matches fingerprint
+func (c *Cache) Get(profileID int, fingerprint string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[profileID]
+	if !ok || e.fingerprint != fingerprint {
+		return nil, false
+	}
+	return e.png, true
+}
+
+// Put stores the card of a profile, replacing any older one
+func (c *Cache) Put(profileID int, fingerprint string, png []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[profileID]; !ok {
+		c.order = append(c.order, profileID)
+	}
+	c.entries[profileID] = cacheEntry{fingerprint: fingerprint, png: png}
+
+	for len(c.order) > c.max {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
@@ -0,0 +1,251 @@
+// Package ogimage draws the 1200x630 preview cards shown when a profile URL
+// is shared on social networks and chat apps.
+package ogimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Card size recommended by Open Graph and Twitter
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// Layout
+const (
+	avatarSize = 240
+	padding    = 80
+	textLeft   = padding + avatarSize + 60
+	textWidth  = Width - textLeft - padding
+)
+
+// Card is everything drawn on a preview image
+type Card struct {
+	Name   string
+	Title  string
+	Footer string      // Shown in a button-colored pill, e.g. the profile URL
+	Avatar image.Image // Optional; initials are drawn without one
+
+	Background [2]color.RGBA // Diagonal gradient; equal stops for a solid color
+	Panel      *color.RGBA   // Boxed layout container, nil for none
+	Accent     color.RGBA    // Button color
+	Text       color.RGBA
+}
+
+var (
+	boldFont    = mustParse(gobold.TTF)
+	regularFont = mustParse(goregular.TTF)
+)
+
+func mustParse(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func newFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		panic(err)
+	}
+	return face
+}
+
+// Render draws a card and encodes it as PNG
+func Render(card Card) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	drawGradient(img, card.Background[0], card.Background[1])
+
+	// Text sits on the panel when there is one, else on the background
+	textBg := mix(card.Background[0], card.Background[1], 0.5)
+	if card.Panel != nil {
+		panel := image.Rect(padding/2, padding/2, Width-padding/2, Height-padding/2)
+		draw.DrawMask(img, panel, image.NewUniform(*card.Panel), image.Point{},
+			&roundedRect{r: panel, radius: 36}, panel.Min, draw.Over)
+		textBg = *card.Panel
+	}
+	text := readable(card.Text, textBg)
+
+	// Avatar, vertically centered
+	avatarRect := image.Rect(padding, (Height-avatarSize)/2, padding+avatarSize, (Height+avatarSize)/2)
+	drawAvatar(img, avatarRect, card)
+
+	nameFace := newFace(boldFont, 72)
+	titleFace := newFace(regularFont, 40)
+	footerFace := newFace(boldFont, 30)
+	defer nameFace.Close()
+	defer titleFace.Close()
+	defer footerFace.Close()
+
+	y := Height/2 - 20
+	if card.Title == "" {
+		y = Height/2 + 10
+	}
+	drawText(img, nameFace, fit(nameFace, card.Name, textWidth), textLeft, y, text)
+	if card.Title != "" {
+		drawText(img, titleFace, fit(titleFace, card.Title, textWidth), textLeft, y+64, withAlpha(text, 210))
+	}
+
+	if card.Footer != "" {
+		label := fit(footerFace, card.Footer, textWidth-56)
+		w := font.MeasureString(footerFace, label).Ceil() + 56
+		pill := image.Rect(textLeft, Height-padding-60, textLeft+w, Height-padding)
+		if card.Panel == nil {
+			pill = pill.Add(image.Pt(0, 20))
+		}
+		draw.DrawMask(img, pill, image.NewUniform(card.Accent), image.Point{},
+			&roundedRect{r: pill, radius: 30}, pill.Min, draw.Over)
+		drawText(img, footerFace, label, pill.Min.X+28, pill.Max.Y-19, readable(color.RGBA{255, 255, 255, 255}, card.Accent))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawGradient(img *image.RGBA, from, to color.RGBA) {
+	b := img.Bounds()
+	span := float64(b.Dx() + b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, mix(from, to, float64(x+y)/span))
+		}
+	}
+}
+
+func drawAvatar(img *image.RGBA, r image.Rectangle, card Card) {
+	mask := &circle{r: r}
+	if card.Avatar != nil {
+		scaled := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), card.Avatar, squareCrop(card.Avatar.Bounds()), draw.Src, nil)
+		draw.DrawMask(img, r, scaled, image.Point{}, mask, r.Min, draw.Over)
+		return
+	}
+
+	draw.DrawMask(img, r, image.NewUniform(card.Accent), image.Point{}, mask, r.Min, draw.Over)
+	face := newFace(boldFont, 96)
+	defer face.Close()
+	label := initials(card.Name)
+	w := font.MeasureString(face, label).Ceil()
+	m := face.Metrics()
+	baseline := r.Min.Y + (r.Dy()+m.Ascent.Ceil()-m.Descent.Ceil())/2
+	drawText(img, face, label, r.Min.X+(r.Dx()-w)/2, baseline, readable(color.RGBA{255, 255, 255, 255}, card.Accent))
+}
+
+func drawText(img *image.RGBA, face font.Face, s string, x, y int, c color.RGBA) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+// fit shortens s with an ellipsis until it is at most width pixels wide
+func fit(face font.Face, s string, width int) string {
+	s = strings.TrimSpace(s)
+	if font.MeasureString(face, s).Ceil() <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if font.MeasureString(face, candidate).Ceil() <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// squareCrop returns the largest centered square of r
+func squareCrop(r image.Rectangle) image.Rectangle {
+	size := min(r.Dx(), r.Dy())
+	x := r.Min.X + (r.Dx()-size)/2
+	y := r.Min.Y + (r.Dy()-size)/2
+	return image.Rect(x, y, x+size, y+size)
+}
+
+// initials returns up to two initials of a name
+func initials(name string) string {
+	var out []rune
+	for _, word := range strings.Fields(name) {
+		r := []rune(word)[0]
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, unicode.ToUpper(r))
+		}
+		if len(out) == 2 {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return "?"
+	}
+	return string(out)
+}
+
+// circle is an anti-aliased circular alpha mask filling r
+type circle struct {
+	r image.Rectangle
+}
+
+func (c *circle) ColorModel() color.Model { return color.AlphaModel }
+func (c *circle) Bounds() image.Rectangle { return c.r }
+
+func (c *circle) At(x, y int) color.Color {
+	radius := float64(c.r.Dx()) / 2
+	cx := float64(c.r.Min.X) + radius
+	cy := float64(c.r.Min.Y) + radius
+	d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+	return coverage(radius - d)
+}
+
+// roundedRect is an anti-aliased rounded rectangle alpha mask filling r
+type roundedRect struct {
+	r      image.Rectangle
+	radius float64
+}
+
+func (rr *roundedRect) ColorModel() color.Model { return color.AlphaModel }
+func (rr *roundedRect) Bounds() image.Rectangle { return rr.r }
+
+func (rr *roundedRect) At(x, y int) color.Color {
+	px, py := float64(x)+0.5, float64(y)+0.5
+	minX, minY := float64(rr.r.Min.X)+rr.radius, float64(rr.r.Min.Y)+rr.radius
+	maxX, maxY := float64(rr.r.Max.X)-rr.radius, float64(rr.r.Max.Y)-rr.radius
+
+	// Distance to the inner rectangle; only corners are rounded
+	dx := math.Max(math.Max(minX-px, 0), px-maxX)
+	dy := math.Max(math.Max(minY-py, 0), py-maxY)
+	return coverage(rr.radius - math.Hypot(dx, dy))
+}
+
+// coverage converts the signed distance from an edge to an alpha value
+func coverage(d float64) color.Alpha {
+	switch {
+	case d >= 1:
+		return color.Alpha{A: 255}
+	case d <= 0:
+		return color.Alpha{}
+	}
+	return color.Alpha{A: uint8(d * 255)}
+}
@@ -0,0 +1,218 @@
+package ogimage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+)
+
+// Bump to regenerate every card after changing the layout
+const layoutVersion = "1"
+
+var (
+	defaultBackground = [2]color.RGBA{{0x66, 0x7e, 0xea, 0xff}, {0x76, 0x4b, 0xa2, 0xff}}
+	defaultAccent     = color.RGBA{0x66, 0x7e, 0xea, 0xff}
+	defaultText       = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	white             = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+var (
+	hexColorPattern = regexp.MustCompile(`#([0-9a-fA-F]{8}|[0-9a-fA-F]{6}|[0-9a-fA-F]{3,4})\b`)
+	rgbColorPattern = regexp.MustCompile(`rgba?\(\s*(\d{1,3})\s*,\s*(\d{1,3})\s*,\s*(\d{1,3})`)
+)
+
+// CardFor builds the card of a profile in its theme's colors. theme may be
+// nil; avatar may be nil to draw initials.
+func CardFor(profile *models.Profile, theme *models.Theme, avatar image.Image, footer string) Card {
+	card := Card{
+		Name:       profile.Name,
+		Footer:     footer,
+		Avatar:     avatar,
+		Background: defaultBackground,
+		Accent:     defaultAccent,
+		Text:       defaultText,
+	}
+	if profile.Title != nil {
+		card.Title = *profile.Title
+	}
+	if theme == nil {
+		return card
+	}
+
+	if c, ok := parseColor(theme.ButtonColor); ok {
+		card.Accent = c
+	}
+	if c, ok := parseColor(theme.TextColor); ok {
+		card.Text = c
+	}
+
+	bgType, bgValue := theme.BgType, theme.BgValue
+	if theme.BoxedEnabled {
+		if theme.BoxedOuterBgType != nil {
+			bgType = *theme.BoxedOuterBgType
+		}
+		if theme.BoxedOuterBgValue != nil {
+			bgValue = theme.BoxedOuterBgValue
+		}
+		panel := white
+		if c, ok := parseColor(theme.BoxedContainerBg); ok {
+			panel = c
+		}
+		card.Panel = &panel
+	}
+	// Background images are not fetched; their card uses the default gradient
+	if bgValue != nil && bgType != "image" {
+		if stops := gradientStops(*bgValue); len(stops) > 0 {
+			card.Background = [2]color.RGBA{stops[0], stops[len(stops)-1]}
+		}
+	}
+	return card
+}
+
+// Fingerprint identifies the inputs of a profile's card; the card only needs
+// to be regenerated when it changes
+func Fingerprint(profile *models.Profile, theme *models.Theme) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%s\x00%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00",
+		layoutVersion, profile.ID, profile.Slug, profile.Name, deref(profile.Title), profile.Avatar, timestamp(profile.UpdatedAt))
+	if theme != nil {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%t\x00%s\x00%s\x00%s\x00%s",
+			theme.BgType, deref(theme.BgValue), theme.ButtonColor, theme.TextColor, theme.BoxedEnabled,
+			deref(theme.BoxedOuterBgType), deref(theme.BoxedOuterBgValue), theme.BoxedContainerBg, timestamp(theme.UpdatedAt))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// gradientStops returns the colors of a solid color or CSS gradient, in order
+func gradientStops(v string) []color.RGBA {
+	type stop struct {
+		at int
+		c  color.RGBA
+	}
+	var stops []stop
+	for _, m := range hexColorPattern.FindAllStringIndex(v, -1) {
+		if c, ok := parseColor(v[m[0]:m[1]]); ok {
+			stops = append(stops, stop{m[0], c})
+		}
+	}
+	for _, m := range rgbColorPattern.FindAllStringIndex(v, -1) {
+		if c, ok := parseColor(v[m[0]:m[1]] + ")"); ok {
+			stops = append(stops, stop{m[0], c})
+		}
+	}
+
+	// Restore source order across both notations
+	for i := 1; i < len(stops); i++ {
+		for j := i; j > 0 && stops[j].at < stops[j-1].at; j-- {
+			stops[j], stops[j-1] = stops[j-1], stops[j]
+		}
+	}
+	colors := make([]color.RGBA, len(stops))
+	for i, s := range stops {
+		colors[i] = s.c
+	}
+	return colors
+}
+
+// parseColor parses #rgb, #rgba, #rrggbb, #rrggbbaa and rgb()/rgba() colors.
+// Transparency is ignored.
+func parseColor(v string) (color.RGBA, bool) {
+	v = strings.TrimSpace(v)
+	if m := rgbColorPattern.FindStringSubmatch(v); m != nil && strings.HasPrefix(v, m[0]) {
+		var c [3]uint8
+		for i := range c {
+			n, err := strconv.Atoi(m[i+1])
+			if err != nil || n > 255 {
+				return color.RGBA{}, false
+			}
+			c[i] = uint8(n)
+		}
+		return color.RGBA{c[0], c[1], c[2], 0xff}, true
+	}
+
+	if !strings.HasPrefix(v, "#") {
+		return color.RGBA{}, false
+	}
+	h := v[1:]
+	if len(h) == 3 || len(h) == 4 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) == 8 {
+		h = h[:6]
+	}
+	if len(h) != 6 {
+		return color.RGBA{}, false
+	}
+	n, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 0xff}, true
+}
+
+// readable returns c, or white/near-black when c would be hard to read on bg
+func readable(c, bg color.RGBA) color.RGBA {
+	if contrast(c, bg) >= 3 {
+		return c
+	}
+	if contrast(white, bg) >= contrast(defaultText, bg) {
+		return white
+	}
+	return color.RGBA{0x1a, 0x1a, 0x1a, 0xff}
+}
+
+// contrast is the WCAG contrast ratio of two colors
+func contrast(a, b color.RGBA) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func luminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+func mix(a, b color.RGBA, t float64) color.RGBA {
+	lerp := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 0xff}
+}
+
+func withAlpha(c color.RGBA, a uint8) color.RGBA {
+	// Premultiplied, as color.RGBA expects
+	scale := func(v uint8) uint8 { return uint8(uint16(v) * uint16(a) / 255) }
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), a}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
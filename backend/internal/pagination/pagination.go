@@ -0,0 +1,197 @@
+// Package pagination implements cursor (keyset) pagination of SQL listings.
+//
+// A listing is an inner query whose rows have an "id" column and a column
+// per sort key. Pages are read with
+//
+//	SELECT * FROM (<inner query>) page
+//	WHERE (page.<sort column>, page.id) < (<cursor value>, <cursor id>)
+//	ORDER BY page.<sort column> DESC, page.id DESC
+//
+// so later pages stay stable while rows are added, and reaching row 10000 is
+// as cheap as reaching row 1.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page sizes
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+)
+
+// Sort is a key a listing can be sorted by
+type Sort struct {
+	Column string // Column of the inner query
+	Type   string // SQL type of its values, e.g. "timestamptz" or "bigint"
+}
+
+// Params selects one page of a listing
+type Params struct {
+	Limit int
+	Sort  string
+	Desc  bool
+	After *Cursor // nil for the first page
+}
+
+// Cursor points at the last row of a page. Clients get it as an opaque
+// string.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"` // Sort column value of the row
+	ID    int64  `json:"i"`
+}
+
+// Page is one page of a listing
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // Empty on the last page
+	Total      int    `json:"total"`                 // Rows matching the filters, on all pages
+}
+
+// FromQuery reads the limit, sort, order and cursor query parameters.
+// Listings are sorted by defaultSort unless asked otherwise, descending
+// (newest or largest first) unless order=asc. A cursor keeps the sort of the
+// page it came from.
+func FromQuery(c *fiber.Ctx, sorts map[string]Sort, defaultSort string) (Params, error) {
+	p := Params{
+		Limit: c.QueryInt("limit", DefaultLimit),
+		Sort:  c.Query("sort", defaultSort),
+		Desc:  c.Query("order") != "asc",
+	}
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			return p, ErrInvalidCursor
+		}
+		p.Sort, p.Desc, p.After = cursor.Sort, cursor.Desc, cursor
+	}
+
+	if _, ok := sorts[p.Sort]; !ok {
+		if p.After != nil {
+			return p, ErrInvalidCursor
+		}
+		return p, ErrInvalidSort
+	}
+	return p, nil
+}
+
+// Query wraps the inner query of a listing so it returns the page, plus one
+// row telling whether there is a next one. args are the arguments of the
+// inner query; the cursor's are appended.
+func (p Params) Query(inner string, sorts map[string]Sort, args []interface{}) (string, []interface{}) {
+	sort := sorts[p.Sort]
+	dir, cmp := "ASC", ">"
+	if p.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	where := ""
+	if p.After != nil {
+		args = append(args, p.After.Value, p.After.ID)
+		where = fmt.Sprintf("WHERE (page.%s, page.id) %s ($%d::%s, $%d)",
+			sort.Column, cmp, len(args)-1, sort.Type, len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT * FROM (%s) page
+		%s
+		ORDER BY page.%s %s, page.id %s
+		LIMIT %d
+	`, inner, where, sort.Column, dir, dir, p.Limit+1)
+	return query, args
+}
+
+// CountQuery counts the rows of a listing on all pages
+func CountQuery(inner string) string {
+	return "SELECT COUNT(*) FROM (" + inner + ") page"
+}
+
+// NewPage trims the rows read with Query to the page and sets its next
+// cursor from the last row. key returns a row's sort column value and id.
+func NewPage[T any](p Params, rows []T, total int, key func(T) (interface{}, int64)) Page[T] {
+	page := Page[T]{Items: rows, Total: total}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(rows) <= p.Limit {
+		return page
+	}
+
+	page.Items = rows[:p.Limit]
+	value, id := key(page.Items[p.Limit-1])
+	page.NextCursor = encodeCursor(&Cursor{Sort: p.Sort, Desc: p.Desc, Value: formatValue(value), ID: id})
+	return page
+}
+
+// Conditions collects the WHERE conditions of a listing with their
+// arguments
+type Conditions struct {
+	clauses []string
+	Args    []interface{}
+}
+
+// Add adds a condition; every "?" in it stands for arg
+func (w *Conditions) Add(cond string, arg interface{}) {
+	w.Args = append(w.Args, arg)
+	w.clauses = append(w.clauses, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(w.Args))))
+}
+
+// SQL returns the WHERE clause, or "" without conditions
+func (w *Conditions) SQL() string {
+	if len(w.clauses) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.clauses, " AND ")
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *time.Time:
+		if v != nil {
+			return v.Format(time.RFC3339Nano)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func encodeCursor(c *Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,181 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var testSorts = map[string]Sort{
+	"created": {Column: "created_at", Type: "timestamptz"},
+	"clicks":  {Column: "clicks", Type: "bigint"},
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Sort: "created", Desc: true, Value: "2024-05-01T10:00:00.123456Z", ID: 42},
+		{Sort: "clicks", Desc: false, Value: "0", ID: 1},
+		{Sort: "name", Value: `quotes " and unicode ü`, ID: 1 << 40},
+		{},
+	}
+	for _, want := range tests {
+		encoded := encodeCursor(&want)
+		if strings.ContainsAny(encoded, "+/=") {
+			t.Errorf("cursor %q is not URL safe", encoded)
+		}
+		got, err := decodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("decodeCursor(%q): %v", encoded, err)
+		}
+		if *got != want {
+			t.Errorf("round trip = %+v, want %+v", *got, want)
+		}
+	}
+
+	for _, raw := range []string{"not base64!", "bm90IGpzb24", "eyJpIjoieCJ9"} {
+		if _, err := decodeCursor(raw); err == nil {
+			t.Errorf("decodeCursor(%q) succeeded", raw)
+		}
+	}
+}
+
+// fromQuery runs FromQuery on a request with the query string
+func fromQuery(t *testing.T, query string) (Params, error) {
+	t.Helper()
+	var p Params
+	var err error
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		p, err = FromQuery(c, testSorts, "created")
+		return nil
+	})
+	if _, testErr := app.Test(httptest.NewRequest("GET", "/?"+query, nil)); testErr != nil {
+		t.Fatal(testErr)
+	}
+	return p, err
+}
+
+func TestFromQuery(t *testing.T) {
+	cursor := &Cursor{Sort: "clicks", Desc: false, Value: "17", ID: 9}
+	unknownSortCursor := &Cursor{Sort: "email", Value: "a", ID: 1}
+
+	tests := []struct {
+		name  string
+		query string
+		want  Params
+		err   error
+	}{
+		{name: "defaults", query: "", want: Params{Limit: DefaultLimit, Sort: "created", Desc: true}},
+		{name: "sort and order", query: "sort=clicks&order=asc&limit=10", want: Params{Limit: 10, Sort: "clicks"}},
+		{name: "limit too large", query: "limit=5000", want: Params{Limit: MaxLimit, Sort: "created", Desc: true}},
+		{name: "limit not positive", query: "limit=-1", want: Params{Limit: DefaultLimit, Sort: "created", Desc: true}},
+		{
+			name:  "cursor keeps its sort",
+			query: "sort=created&order=desc&limit=20&cursor=" + encodeCursor(cursor),
+			want:  Params{Limit: 20, Sort: "clicks", Desc: false, After: cursor},
+		},
+		{name: "unknown sort", query: "sort=email", err: ErrInvalidSort},
+		{name: "garbled cursor", query: "cursor=%25%25", err: ErrInvalidCursor},
+		{name: "cursor of another list", query: "cursor=" + encodeCursor(unknownSortCursor), err: ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fromQuery(t, tt.query)
+			if err != tt.err {
+				t.Fatalf("FromQuery error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromQuery = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	inner := "SELECT id, clicks FROM links WHERE user_id = $1"
+	squash := func(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+	tests := []struct {
+		name   string
+		params Params
+		query  string
+		args   []interface{}
+	}{
+		{
+			name:   "first page",
+			params: Params{Limit: 10, Sort: "clicks", Desc: true},
+			query:  "SELECT * FROM (" + inner + ") page ORDER BY page.clicks DESC, page.id DESC LIMIT 11",
+			args:   []interface{}{7},
+		},
+		{
+			name:   "after cursor ascending",
+			params: Params{Limit: 10, Sort: "created", After: &Cursor{Value: "2024-05-01T10:00:00Z", ID: 3}},
+			query: "SELECT * FROM (" + inner + ") page " +
+				"WHERE (page.created_at, page.id) > ($2::timestamptz, $3) " +
+				"ORDER BY page.created_at ASC, page.id ASC LIMIT 11",
+			args: []interface{}{7, "2024-05-01T10:00:00Z", int64(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.params.Query(inner, testSorts, []interface{}{7})
+			if squash(query) != tt.query {
+				t.Errorf("query = %s\nwant %s", squash(query), tt.query)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	type row struct {
+		id      int64
+		created time.Time
+	}
+	created := time.Date(2024, 5, 1, 10, 0, 0, 500, time.UTC)
+	rows := []row{{5, created.Add(2 * time.Hour)}, {4, created.Add(time.Hour)}, {3, created}}
+	key := func(r row) (interface{}, int64) { return r.created, r.id }
+	p := Params{Limit: 2, Sort: "created", Desc: true}
+
+	page := NewPage(p, rows, 10, key)
+	if len(page.Items) != 2 || page.Total != 10 {
+		t.Fatalf("page has %d items of %d, want 2 of 10", len(page.Items), page.Total)
+	}
+	next, err := decodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("next cursor: %v", err)
+	}
+	want := Cursor{Sort: "created", Desc: true, Value: "2024-05-01T11:00:00.0000005Z", ID: 4}
+	if *next != want {
+		t.Errorf("next cursor = %+v, want %+v", *next, want)
+	}
+
+	last := NewPage(p, rows[:2], 2, key)
+	if last.NextCursor != "" || len(last.Items) != 2 {
+		t.Errorf("last page = %d items, cursor %q", len(last.Items), last.NextCursor)
+	}
+	if empty := NewPage[row](p, nil, 0, key); empty.Items == nil {
+		t.Error("empty page items are nil, want []")
+	}
+}
+
+func TestConditions(t *testing.T) {
+	var w Conditions
+	if w.SQL() != "" {
+		t.Errorf("empty SQL() = %q", w.SQL())
+	}
+	w.Add("is_active = ?", true)
+	w.Add("(username ILIKE ? OR email ILIKE ?)", "%jo%")
+	if want := "WHERE is_active = $1 AND (username ILIKE $2 OR email ILIKE $2)"; w.SQL() != want {
+		t.Errorf("SQL() = %q, want %q", w.SQL(), want)
+	}
+	if !reflect.DeepEqual(w.Args, []interface{}{true, "%jo%"}) {
+		t.Errorf("Args = %v", w.Args)
+	}
+}
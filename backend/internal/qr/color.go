@@ -0,0 +1,48 @@
+package qr
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseHex parses #rgb, #rgba, #rrggbb and #rrggbbaa colors; alpha is ignored
+func ParseHex(v string) (color.RGBA, bool) {
+	h, ok := strings.CutPrefix(strings.TrimSpace(v), "#")
+	if !ok {
+		return color.RGBA{}, false
+	}
+	if len(h) == 3 || len(h) == 4 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) == 8 {
+		h = h[:6]
+	}
+	if len(h) != 6 {
+		return color.RGBA{}, false
+	}
+	n, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 0xff}, true
+}
+
+// Scannable reports whether fg on bg is likely to scan: most readers expect
+// dark modules on a light background with clear contrast
+func Scannable(fg, bg color.RGBA) bool {
+	lf, lb := luminance(fg), luminance(bg)
+	return lf < lb && (lb+0.05)/(lf+0.05) >= 3
+}
+
+func luminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
@@ -0,0 +1,217 @@
+// Package qr renders QR codes as PNG or SVG with custom colors, quiet zone
+// and an optional logo in the center.
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+)
+
+// Limits of Options.Size and Options.Margin
+const (
+	MinSize   = 128
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+// Level is the error correction level: the share of the code that can be
+// damaged or covered while it still scans
+type Level string
+
+const (
+	LevelLow     Level = "L" // ~7%
+	LevelMedium  Level = "M" // ~15%
+	LevelQuart   Level = "Q" // ~25%
+	LevelHighest Level = "H" // ~30%
+)
+
+var recoveryLevels = map[Level]qrcode.RecoveryLevel{
+	LevelLow:     qrcode.Low,
+	LevelMedium:  qrcode.Medium,
+	LevelQuart:   qrcode.High,
+	LevelHighest: qrcode.Highest,
+}
+
+// Share of the code width covered by a logo; well within what level H
+// recovers
+const logoShare = 0.22
+
+// Options controls how a code is drawn
+type Options struct {
+	Size       int   // Width and height in pixels
+	Margin     int   // Quiet zone in modules
+	Level      Level // Raised to H when a logo is set
+	Foreground color.RGBA
+	Background color.RGBA
+	Logo       image.Image // Optional
+}
+
+// DefaultOptions returns black-on-white 512px codes with the standard quiet zone
+func DefaultOptions() Options {
+	return Options{
+		Size:       512,
+		Margin:     4,
+		Level:      LevelMedium,
+		Foreground: color.RGBA{0, 0, 0, 0xff},
+		Background: color.RGBA{0xff, 0xff, 0xff, 0xff},
+	}
+}
+
+// modules encodes content and returns the module grid without quiet zone
+func modules(content string, opts Options) ([][]bool, error) {
+	level := opts.Level
+	if opts.Logo != nil {
+		level = LevelHighest
+	}
+	recovery, ok := recoveryLevels[level]
+	if !ok {
+		recovery = qrcode.Medium
+	}
+
+	code, err := qrcode.New(content, recovery)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	return code.Bitmap(), nil
+}
+
+// PNG renders content as a PNG of opts.Size pixels. Modules are whole pixels,
+// so the quiet zone absorbs any rounding.
+func PNG(content string, opts Options) ([]byte, error) {
+	grid, err := modules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts = clamp(opts)
+
+	n := len(grid)
+	scale := max(1, opts.Size/(n+2*opts.Margin))
+	size := max(opts.Size, n*scale)
+	offset := (size - n*scale) / 2
+
+	var img draw.Image
+	if opts.Logo == nil {
+		// Two colors keep the file small
+		img = image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{opts.Background, opts.Foreground})
+	} else {
+		img = image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	}
+
+	fg := image.NewUniform(opts.Foreground)
+	for y, row := range grid {
+		for x, dark := range row {
+			if dark {
+				r := image.Rect(offset+x*scale, offset+y*scale, offset+(x+1)*scale, offset+(y+1)*scale)
+				draw.Draw(img, r, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if opts.Logo != nil {
+		side := int(float64(n*scale) * logoShare)
+		pad := max(scale, side/10)
+		x0 := (size - side) / 2
+		backdrop := image.Rect(x0-pad, x0-pad, x0+side+pad, x0+side+pad)
+		draw.Draw(img, backdrop, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(img, image.Rect(x0, x0, x0+side, x0+side), opts.Logo, squareCrop(opts.Logo.Bounds()), draw.Over, nil)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders content as a scalable SVG document of opts.Size pixels
+func SVG(content string, opts Options) ([]byte, error) {
+	grid, err := modules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts = clamp(opts)
+
+	n := len(grid)
+	total := n + 2*opts.Margin
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, total, total, hexColor(opts.Background))
+
+	// One path; horizontal runs of dark modules become a single rectangle
+	fmt.Fprintf(&b, `<path fill="%s" d="`, hexColor(opts.Foreground))
+	for y, row := range grid {
+		for x := 0; x < n; {
+			if !row[x] {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < n && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x+opts.Margin, y+opts.Margin, run, run)
+			x += run
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if opts.Logo != nil {
+		logo, err := logoPNG(opts.Logo)
+		if err != nil {
+			return nil, err
+		}
+		side := float64(n) * logoShare
+		pad := max(1, side/10)
+		x0 := (float64(total) - side) / 2
+		fmt.Fprintf(&b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`,
+			x0-pad, x0-pad, side+2*pad, side+2*pad, hexColor(opts.Background))
+		fmt.Fprintf(&b, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" preserveAspectRatio="xMidYMid slice" href="data:image/png;base64,%s"/>`,
+			x0, x0, side, side, logo)
+	}
+
+	b.WriteString(`</svg>`)
+	return []byte(b.String()), nil
+}
+
+// logoPNG downsizes a logo for embedding and returns it base64 encoded
+func logoPNG(logo image.Image) (string, error) {
+	const side = 256
+	img := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.CatmullRom.Scale(img, img.Bounds(), logo, squareCrop(logo.Bounds()), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func clamp(opts Options) Options {
+	opts.Size = max(MinSize, min(opts.Size, MaxSize))
+	opts.Margin = max(0, min(opts.Margin, MaxMargin))
+	return opts
+}
+
+// squareCrop returns the largest centered square of r
+func squareCrop(r image.Rectangle) image.Rectangle {
+	size := min(r.Dx(), r.Dy())
+	x := r.Min.X + (r.Dx()-size)/2
+	y := r.Min.Y + (r.Dy()-size)/2
+	return image.Rect(x, y, x+size, y+size)
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,233 @@
+// Package render produces the server-rendered public profile pages, so that
+// profiles work without JavaScript and can be read by crawlers and link
+// preview bots.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/ogimage"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/storage"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// Avatar value of profiles that never uploaded one
+const defaultAvatar = "default-avatar.png"
+
+// Renderer renders pages; canonical and preview URLs are built from the
+// public base URL and asset URLs by the storage backend
+type Renderer struct {
+	baseURL string
+	storage storage.Storage
+}
+
+func New(baseURL string, store storage.Storage) *Renderer {
+	return &Renderer{baseURL: strings.TrimRight(baseURL, "/"), storage: store}
+}
+
+// Meta describes a page to browsers, crawlers and link previews
+type Meta struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	NoIndex     bool
+}
+
+type profilePage struct {
+	Meta
+	Style      themeStyle
+	Name       string
+	Heading    string
+	Bio        string
+	AvatarURL  string
+	Initials   string
+	IsVerified bool
+	Sections   []section
+}
+
+// section is a group of links: uncategorized links first, then one section
+// per category
+type section struct {
+	Name     string
+	Icon     string
+	Expanded bool
+	Links    []linkItem
+}
+
+type linkItem struct {
+	Title string
+	Icon  string
+	Href  string
+}
+
+type passwordPage struct {
+	Meta
+	Style themeStyle
+	Name  string
+	Error string
+}
+
+// Profile renders a public profile page. Links point at the /r/:id redirect
+// endpoint so clicks are tracked without JavaScript.
+func (r *Renderer) Profile(data *models.PublicProfile) ([]byte, error) {
+	p := &data.Profile
+	page := profilePage{
+		Meta:       r.profileMeta(p, &data.Theme),
+		Style:      newThemeStyle(&data.Theme),
+		Name:       p.Name,
+		Heading:    p.Name,
+		AvatarURL:  r.avatarURL(p.Avatar),
+		Initials:   initials(p.Name),
+		IsVerified: data.IsVerified,
+		Sections:   sections(data.Categories, data.Links),
+	}
+	if p.Title != nil && *p.Title != "" {
+		page.Heading = *p.Title
+	}
+	if p.Bio != nil {
+		page.Bio = *p.Bio
+	}
+	return execute("profile.html", page)
+}
+
+// Password renders the unlock form of a password-protected profile. message
+// is shown after a refused attempt.
+func (r *Renderer) Password(profile *models.Profile, theme *models.Theme, message string) ([]byte, error) {
+	meta := Meta{
+		Title:       profile.Name + " - LinkMy",
+		Description: "This profile is password protected",
+		URL:         r.baseURL + "/" + profile.Slug,
+		NoIndex:     true,
+	}
+	return execute("password.html", passwordPage{
+		Meta:  meta,
+		Style: newThemeStyle(theme),
+		Name:  profile.Name,
+		Error: message,
+	})
+}
+
+// NotFound renders the page for unknown slugs
+func (r *Renderer) NotFound() ([]byte, error) {
+	return execute("notfound.html", struct {
+		Meta
+		Style themeStyle
+	}{
+		Meta:  Meta{Title: "Profile not found - LinkMy", NoIndex: true},
+		Style: newThemeStyle(nil),
+	})
+}
+
+func (r *Renderer) profileMeta(p *models.Profile, theme *models.Theme) Meta {
+	meta := Meta{
+		Title:       p.Name + " - LinkMy",
+		Description: "Check out " + p.Name + "'s links on LinkMy",
+		URL:         r.baseURL + "/" + p.Slug,
+		NoIndex:     !p.IsIndexable(),
+	}
+	// Preview card; the version makes link preview caches pick up changes.
+	// Protected profiles have none.
+	if p.Visibility != models.VisibilityPassword {
+		meta.Image = r.baseURL + "/og/" + p.Slug + ".png?v=" + ogimage.Fingerprint(p, theme)[:8]
+	}
+	if p.Title != nil && *p.Title != "" {
+		meta.Title = *p.Title + " - LinkMy"
+	}
+	if p.Bio != nil && *p.Bio != "" {
+		meta.Description = truncate(*p.Bio, 200)
+	}
+	return meta
+}
+
+func sections(categories []models.Category, links []models.Link) []section {
+	uncategorized := section{Expanded: true}
+	byCategory := make(map[int]*section, len(categories))
+	grouped := make([]*section, 0, len(categories))
+	for _, cat := range categories {
+		s := &section{Name: cat.Name, Icon: cat.Icon, Expanded: cat.IsExpanded}
+		byCategory[cat.ID] = s
+		grouped = append(grouped, s)
+	}
+
+	for _, l := range links {
+		item := linkItem{Title: l.Title, Icon: l.Icon, Href: "/r/" + strconv.Itoa(l.ID)}
+		if l.CategoryID != nil {
+			if s, ok := byCategory[*l.CategoryID]; ok {
+				s.Links = append(s.Links, item)
+				continue
+			}
+		}
+		uncategorized.Links = append(uncategorized.Links, item)
+	}
+
+	var result []section
+	if len(uncategorized.Links) > 0 {
+		result = append(result, uncategorized)
+	}
+	for _, s := range grouped {
+		if len(s.Links) > 0 {
+			result = append(result, *s)
+		}
+	}
+	return result
+}
+
+// avatarURL returns an absolute avatar URL, or "" when the profile has none.
+// Stored avatars are served by the storage backend.
+func (r *Renderer) avatarURL(avatar string) string {
+	if avatar == "" || avatar == defaultAvatar {
+		return ""
+	}
+	if key, ok := storage.KeyFromRef(r.storage, avatar); ok {
+		return r.storage.URL(key)
+	}
+	if strings.HasPrefix(avatar, "https://") || strings.HasPrefix(avatar, "http://") {
+		return avatar
+	}
+	return ""
+}
+
+func execute(name string, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// initials returns up to two initials of a name for the avatar placeholder
+func initials(name string) string {
+	var out []rune
+	for _, word := range strings.Fields(name) {
+		r := []rune(word)[0]
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, unicode.ToUpper(r))
+		}
+		if len(out) == 2 {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return "?"
+	}
+	return string(out)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
@@ -0,0 +1,33 @@
+package render
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/themecss"
+)
+
+// themeStyle is the sanitized presentation of a theme
+type themeStyle struct {
+	Vars     template.CSS // CSS custom properties for :root
+	Rules    template.CSS // Uploaded font and custom CSS
+	Classes  string       // Classes for <body>
+	FontLink string       // Google Fonts stylesheet
+}
+
+func newThemeStyle(t *models.Theme) themeStyle {
+	var settings *models.ThemeSettings
+	if t != nil {
+		settings = &t.ThemeSettings
+	}
+	style := themecss.Build(settings)
+
+	return themeStyle{
+		// themecss only emits values it parsed and re-serialized
+		Vars:     template.CSS(style.Declarations()),
+		Rules:    template.CSS(style.Rules()),
+		Classes:  strings.Join(style.Classes, " "),
+		FontLink: style.FontURL(),
+	}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AssetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAssetRepository(db *pgxpool.Pool) *AssetRepository {
+	return &AssetRepository{db: db}
+}
+
+// Assets no longer used as an avatar, background or font anywhere,
+// including in theme presets, restorable theme versions and publications.
+// Assets of deleted profiles qualify at once, others once created before $1.
+const orphanedAssetCondition = `
+	(a.profile_id IS NULL OR a.created_at < $1)
+	AND NOT EXISTS (SELECT 1 FROM profiles p WHERE p.avatar = a.url)
+	AND NOT EXISTS (
+		SELECT 1 FROM themes t
+		WHERE t.bg_value = a.url OR t.boxed_outer_bg_value = a.url OR t.custom_font_url = a.url
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM theme_presets tp
+		WHERE tp.settings->>'bg_value' = a.url OR tp.settings->>'boxed_outer_bg_value' = a.url
+			OR tp.settings->>'custom_font_url' = a.url
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM theme_versions tv
+		WHERE tv.settings->>'bg_value' = a.url OR tv.settings->>'boxed_outer_bg_value' = a.url
+			OR tv.settings->>'custom_font_url' = a.url
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM profile_publications pp
+		WHERE pp.content->>'avatar' = a.url
+			OR pp.content->'theme'->>'bg_value' = a.url OR pp.content->'theme'->>'boxed_outer_bg_value' = a.url
+			OR pp.content->'theme'->>'custom_font_url' = a.url
+	)
+`
+
+// Create records a stored asset. Keys are derived from the content, so
+// uploading the same image again reuses the existing row and restarts its
+// grace period.
+func (r *AssetRepository) Create(ctx context.Context, asset *models.Asset) error {
+	query := `
+		INSERT INTO assets (user_id, profile_id, kind, storage_key, url, content_type, size_bytes, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (storage_key) DO UPDATE
+		SET user_id = EXCLUDED.user_id, profile_id = EXCLUDED.profile_id, created_at = NOW()
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		asset.UserID, asset.ProfileID, asset.Kind, asset.StorageKey, asset.URL,
+		asset.ContentType, asset.SizeBytes, asset.Width, asset.Height,
+	).Scan(&asset.ID, &asset.CreatedAt)
+}
+
+// ListOrphaned returns assets that are no longer used, oldest first. Unused
+// assets of existing profiles are only returned once created before
+// unusedBefore.
+func (r *AssetRepository) ListOrphaned(ctx context.Context, unusedBefore time.Time, limit int) ([]models.Asset, error) {
+	query := `
+		SELECT a.id, a.user_id, a.profile_id, a.kind, a.storage_key, a.url, a.content_type,
+			   a.size_bytes, a.width, a.height, a.created_at
+		FROM assets a
+		WHERE ` + orphanedAssetCondition + `
+		ORDER BY a.id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, unusedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []models.Asset
+	for rows.Next() {
+		var a models.Asset
+		if err := rows.Scan(
+			&a.ID, &a.UserID, &a.ProfileID, &a.Kind, &a.StorageKey, &a.URL, &a.ContentType,
+			&a.SizeBytes, &a.Width, &a.Height, &a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
+
+// DeleteOrphaned deletes the row of an asset if it is still unused. Returns
+// false when the asset was taken into use again or is already gone.
+func (r *AssetRepository) DeleteOrphaned(ctx context.Context, id int64, unusedBefore time.Time) (bool, error) {
+	query := "DELETE FROM assets a WHERE a.id = $2 AND " + orphanedAssetCondition
+	result, err := r.db.Exec(ctx, query, unusedBefore, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
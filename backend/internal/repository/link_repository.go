@@ -32,27 +32,39 @@ func (r *LinkRepository) Create(ctx context.Context, link *models.Link) error {
 	}
 
 	query := `
-		INSERT INTO links (profile_id, category_id, title, url, icon, position, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO links (profile_id, category_id, title, url, icon, position, is_active, split_mode,
+			screening_status, screening_reasons, screened_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at
 	`
+	if link.SplitMode == "" {
+		link.SplitMode = models.SplitWeighted
+	}
+	if link.ScreeningStatus == "" {
+		link.ScreeningStatus = models.ScreeningPending
+	}
+	if link.ScreeningReasons == nil {
+		link.ScreeningReasons = []string{}
+	}
 	return r.db.QueryRow(ctx, query,
 		link.ProfileID, link.CategoryID, link.Title, link.URL,
-		link.Icon, link.Position, link.IsActive,
+		link.Icon, link.Position, link.IsActive, link.SplitMode,
+		link.ScreeningStatus, link.ScreeningReasons, link.ScreenedAt,
 	).Scan(&link.ID, &link.CreatedAt)
 }
 
 // GetByID retrieves a link by ID
 func (r *LinkRepository) GetByID(ctx context.Context, id int) (*models.Link, error) {
 	query := `
-		SELECT id, profile_id, category_id, title, url, icon, position, clicks, is_active, created_at, updated_at
+		SELECT id, profile_id, category_id, title, url, icon, position, clicks, is_active, split_mode,
+			   created_at, updated_at, screening_status, screening_reasons, screened_at
 		FROM links WHERE id = $1
 	`
 	link := &models.Link{}
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&link.ID, &link.ProfileID, &link.CategoryID, &link.Title, &link.URL,
-		&link.Icon, &link.Position, &link.Clicks, &link.IsActive,
-		&link.CreatedAt, &link.UpdatedAt,
+		&link.Icon, &link.Position, &link.Clicks, &link.IsActive, &link.SplitMode,
+		&link.CreatedAt, &link.UpdatedAt, &link.ScreeningStatus, &link.ScreeningReasons, &link.ScreenedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -66,7 +78,8 @@ func (r *LinkRepository) GetByID(ctx context.Context, id int) (*models.Link, err
 // GetByProfileID retrieves all links for a profile
 func (r *LinkRepository) GetByProfileID(ctx context.Context, profileID int, activeOnly bool) ([]models.Link, error) {
 	query := `
-		SELECT id, profile_id, category_id, title, url, icon, position, clicks, is_active, created_at, updated_at
+		SELECT id, profile_id, category_id, title, url, icon, position, clicks, is_active, split_mode,
+			   created_at, updated_at, screening_status, screening_reasons, screened_at
 		FROM links WHERE profile_id = $1
 	`
 	if activeOnly {
@@ -85,8 +98,8 @@ func (r *LinkRepository) GetByProfileID(ctx context.Context, profileID int, acti
 		var l models.Link
 		err := rows.Scan(
 			&l.ID, &l.ProfileID, &l.CategoryID, &l.Title, &l.URL,
-			&l.Icon, &l.Position, &l.Clicks, &l.IsActive,
-			&l.CreatedAt, &l.UpdatedAt,
+			&l.Icon, &l.Position, &l.Clicks, &l.IsActive, &l.SplitMode,
+			&l.CreatedAt, &l.UpdatedAt, &l.ScreeningStatus, &l.ScreeningReasons, &l.ScreenedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -100,13 +113,18 @@ func (r *LinkRepository) GetByProfileID(ctx context.Context, profileID int, acti
 func (r *LinkRepository) Update(ctx context.Context, link *models.Link) error {
 	query := `
 		UPDATE links SET category_id = $1, title = $2, url = $3, icon = $4, 
-			   position = $5, is_active = $6, updated_at = $7
-		WHERE id = $8
+			   position = $5, is_active = $6, split_mode = $7, updated_at = $8,
+			   screening_status = $9, screening_reasons = $10, screened_at = $11
+		WHERE id = $12
 	`
 	now := time.Now()
+	if link.ScreeningReasons == nil {
+		link.ScreeningReasons = []string{}
+	}
 	result, err := r.db.Exec(ctx, query,
 		link.CategoryID, link.Title, link.URL, link.Icon,
-		link.Position, link.IsActive, now, link.ID,
+		link.Position, link.IsActive, link.SplitMode, now,
+		link.ScreeningStatus, link.ScreeningReasons, link.ScreenedAt, link.ID,
 	)
 	if err != nil {
 		return err
@@ -138,12 +156,13 @@ func (r *LinkRepository) IncrementClicks(ctx context.Context, id int) error {
 // RecordClick records a click event for analytics
 func (r *LinkRepository) RecordClick(ctx context.Context, click *models.Click) error {
 	query := `
-		INSERT INTO clicks (link_id, ip, country, city, user_agent, referrer)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO clicks (link_id, variant_id, rule_id, target, ip, country, city, user_agent, referrer, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, clicked_at
 	`
 	return r.db.QueryRow(ctx, query,
-		click.LinkID, click.IP, click.Country, click.City, click.UserAgent, click.Referrer,
+		click.LinkID, click.VariantID, click.RuleID, click.Target, click.IP, click.Country, click.City,
+		click.UserAgent, click.Referrer, click.Source,
 	).Scan(&click.ID, &click.ClickedAt)
 }
 
@@ -184,3 +203,87 @@ func (r *LinkRepository) GetProfileOwner(ctx context.Context, linkID int) (int,
 	}
 	return userID, nil
 }
+
+// GetDueForScreening returns links whose destinations have not been screened
+// since before, least recently screened first. Held and approved links are
+// left to the admin review queue.
+func (r *LinkRepository) GetDueForScreening(ctx context.Context, before time.Time, limit int) ([]models.Link, error) {
+	query := `
+		SELECT id, profile_id, category_id, title, url, icon, position, clicks, is_active, split_mode,
+			   created_at, updated_at, screening_status, screening_reasons, screened_at
+		FROM links
+		WHERE screening_status IN ('pending', 'clean') AND (screened_at IS NULL OR screened_at < $1)
+		ORDER BY screened_at ASC NULLS FIRST, id ASC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []models.Link
+	for rows.Next() {
+		var l models.Link
+		err := rows.Scan(
+			&l.ID, &l.ProfileID, &l.CategoryID, &l.Title, &l.URL,
+			&l.Icon, &l.Position, &l.Clicks, &l.IsActive, &l.SplitMode,
+			&l.CreatedAt, &l.UpdatedAt, &l.ScreeningStatus, &l.ScreeningReasons, &l.ScreenedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// GetDestinations returns the variant and targeting rule URLs of a link,
+// and its URL in the live publication when that differs from the draft
+func (r *LinkRepository) GetDestinations(ctx context.Context, linkID int) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT url FROM link_variants WHERE link_id = $1
+		UNION
+		SELECT url FROM link_rules WHERE link_id = $1
+		UNION
+		SELECT pl->>'url'
+		FROM links l
+		JOIN profile_publications p ON p.profile_id = l.profile_id AND p.is_live
+		CROSS JOIN jsonb_array_elements(p.content->'links') pl
+		WHERE l.id = $1 AND (pl->>'id')::int = $1 AND pl->>'url' <> l.url
+	`, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, rows.Err()
+}
+
+// SetScreening stores a screening result. Links that end up flagged or
+// blocked are deactivated.
+func (r *LinkRepository) SetScreening(ctx context.Context, id int, status string, reasons []string) error {
+	if reasons == nil {
+		reasons = []string{}
+	}
+	result, err := r.db.Exec(ctx, `
+		UPDATE links SET screening_status = $1, screening_reasons = $2, screened_at = NOW(),
+			is_active = CASE WHEN $1 IN ('flagged', 'blocked') THEN false ELSE is_active END
+		WHERE id = $3
+	`, status, reasons, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
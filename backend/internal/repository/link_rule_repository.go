@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LinkRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLinkRuleRepository(db *pgxpool.Pool) *LinkRuleRepository {
+	return &LinkRuleRepository{db: db}
+}
+
+// Create adds a targeting rule to a link
+func (r *LinkRuleRepository) Create(ctx context.Context, rule *models.LinkRule) error {
+	query := `
+		INSERT INTO link_rules (link_id, rule_type, match_values, url, priority, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		rule.LinkID, rule.RuleType, rule.MatchValues, rule.URL, rule.Priority, rule.IsActive,
+	).Scan(&rule.ID, &rule.CreatedAt)
+}
+
+// GetByID retrieves a rule by ID
+func (r *LinkRuleRepository) GetByID(ctx context.Context, id int) (*models.LinkRule, error) {
+	query := `
+		SELECT id, link_id, rule_type, match_values, url, priority, is_active, created_at, updated_at
+		FROM link_rules WHERE id = $1
+	`
+	rule := &models.LinkRule{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&rule.ID, &rule.LinkID, &rule.RuleType, &rule.MatchValues, &rule.URL,
+		&rule.Priority, &rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetByLinkID retrieves the rules of a link in evaluation order
+func (r *LinkRuleRepository) GetByLinkID(ctx context.Context, linkID int, activeOnly bool) ([]models.LinkRule, error) {
+	query := `
+		SELECT id, link_id, rule_type, match_values, url, priority, is_active, created_at, updated_at
+		FROM link_rules WHERE link_id = $1
+	`
+	if activeOnly {
+		query += " AND is_active = true"
+	}
+	query += " ORDER BY priority ASC, id ASC"
+
+	rows, err := r.db.Query(ctx, query, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.LinkRule{}
+	for rows.Next() {
+		var rule models.LinkRule
+		err := rows.Scan(
+			&rule.ID, &rule.LinkID, &rule.RuleType, &rule.MatchValues, &rule.URL,
+			&rule.Priority, &rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Update updates a rule
+func (r *LinkRuleRepository) Update(ctx context.Context, rule *models.LinkRule) error {
+	query := `
+		UPDATE link_rules SET match_values = $1, url = $2, priority = $3, is_active = $4, updated_at = $5
+		WHERE id = $6
+	`
+	result, err := r.db.Exec(ctx, query,
+		rule.MatchValues, rule.URL, rule.Priority, rule.IsActive, time.Now(), rule.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete deletes a rule
+func (r *LinkRuleRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM link_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
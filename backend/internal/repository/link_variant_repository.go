@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LinkVariantRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLinkVariantRepository(db *pgxpool.Pool) *LinkVariantRepository {
+	return &LinkVariantRepository{db: db}
+}
+
+// Create adds a destination variant to a link
+func (r *LinkVariantRepository) Create(ctx context.Context, variant *models.LinkVariant) error {
+	query := `
+		INSERT INTO link_variants (link_id, label, url, weight, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		variant.LinkID, variant.Label, variant.URL, variant.Weight, variant.IsActive,
+	).Scan(&variant.ID, &variant.CreatedAt)
+}
+
+// GetByID retrieves a variant by ID
+func (r *LinkVariantRepository) GetByID(ctx context.Context, id int) (*models.LinkVariant, error) {
+	query := `
+		SELECT id, link_id, label, url, weight, is_active, created_at, updated_at
+		FROM link_variants WHERE id = $1
+	`
+	v := &models.LinkVariant{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&v.ID, &v.LinkID, &v.Label, &v.URL, &v.Weight, &v.IsActive, &v.CreatedAt, &v.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetByLinkID retrieves all variants of a link
+func (r *LinkVariantRepository) GetByLinkID(ctx context.Context, linkID int, activeOnly bool) ([]models.LinkVariant, error) {
+	query := `
+		SELECT id, link_id, label, url, weight, is_active, created_at, updated_at
+		FROM link_variants WHERE link_id = $1
+	`
+	if activeOnly {
+		query += " AND is_active = true AND weight > 0"
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := r.db.Query(ctx, query, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []models.LinkVariant{}
+	for rows.Next() {
+		var v models.LinkVariant
+		err := rows.Scan(
+			&v.ID, &v.LinkID, &v.Label, &v.URL, &v.Weight, &v.IsActive, &v.CreatedAt, &v.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+// Update updates a variant
+func (r *LinkVariantRepository) Update(ctx context.Context, variant *models.LinkVariant) error {
+	query := `
+		UPDATE link_variants SET label = $1, url = $2, weight = $3, is_active = $4, updated_at = $5
+		WHERE id = $6
+	`
+	result, err := r.db.Exec(ctx, query,
+		variant.Label, variant.URL, variant.Weight, variant.IsActive, time.Now(), variant.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete deletes a variant (recorded clicks keep a NULL variant)
+func (r *LinkVariantRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM link_variants WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
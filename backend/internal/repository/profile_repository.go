@@ -10,6 +10,16 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// How long a released slug stays reserved for its former owner
+const SlugQuarantine = 30 * 24 * time.Hour
+
+// SlugConflict tells why a slug cannot be claimed
+type SlugConflict struct {
+	Taken       bool // Used by another profile
+	Similar     bool // Another user's profile has a slug with the same skeleton
+	Quarantined bool // Recently released by another user
+}
+
 type ProfileRepository struct {
 	db *pgxpool.Pool
 }
@@ -28,13 +38,16 @@ func (r *ProfileRepository) Create(ctx context.Context, profile *models.Profile)
 
 	// Insert profile
 	query := `
-		INSERT INTO profiles (user_id, slug, name, title, bio, avatar, is_active, display_order)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO profiles (user_id, slug, name, title, bio, avatar, is_active, visibility, password_hash, display_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at
 	`
+	if profile.Visibility == "" {
+		profile.Visibility = models.VisibilityPublic
+	}
 	err = tx.QueryRow(ctx, query,
 		profile.UserID, profile.Slug, profile.Name, profile.Title, profile.Bio,
-		profile.Avatar, profile.IsActive, profile.DisplayOrder,
+		profile.Avatar, profile.IsActive, profile.Visibility, profile.PasswordHash, profile.DisplayOrder,
 	).Scan(&profile.ID, &profile.CreatedAt)
 	
 	if err != nil {
@@ -44,15 +57,26 @@ func (r *ProfileRepository) Create(ctx context.Context, profile *models.Profile)
 		return err
 	}
 
-	// Create default theme
-	themeQuery := `
-		INSERT INTO themes (profile_id, bg_type, bg_value, button_style, button_color, text_color, font)
-		VALUES ($1, 'gradient', 'linear-gradient(135deg, #667eea 0%, #764ba2 100%)', 'rounded', '#667eea', '#333333', 'Inter')
-	`
-	_, err = tx.Exec(ctx, themeQuery, profile.ID)
+	// A claimed slug no longer redirects anywhere
+	if _, err := tx.Exec(ctx, "DELETE FROM profile_slug_history WHERE slug = $1", profile.Slug); err != nil {
+		return err
+	}
+
+	// Create the theme from the default preset, or from the column defaults
+	// if there is none
+	var settings models.ThemeSettings
+	err = tx.QueryRow(ctx, "SELECT settings FROM theme_presets WHERE is_default").Scan(&settings)
+	if errors.Is(err, pgx.ErrNoRows) {
+		_, err = tx.Exec(ctx, "INSERT INTO themes (profile_id) VALUES ($1)", profile.ID)
+	} else if err == nil {
+		err = insertTheme(ctx, tx, profile.ID, &settings)
+	}
 	if err != nil {
 		return err
 	}
+	if err := recordThemeVersion(ctx, tx, profile.ID, models.ThemeSourceCreate); err != nil {
+		return err
+	}
 
 	return tx.Commit(ctx)
 }
@@ -60,14 +84,15 @@ func (r *ProfileRepository) Create(ctx context.Context, profile *models.Profile)
 // GetByID retrieves a profile by ID
 func (r *ProfileRepository) GetByID(ctx context.Context, id int) (*models.Profile, error) {
 	query := `
-		SELECT id, user_id, slug, name, title, bio, avatar, is_active, display_order, created_at, updated_at
+		SELECT id, user_id, slug, name, title, bio, avatar, is_active, visibility, password_hash,
+			   display_order, created_at, updated_at
 		FROM profiles WHERE id = $1
 	`
 	profile := &models.Profile{}
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&profile.ID, &profile.UserID, &profile.Slug, &profile.Name, &profile.Title,
-		&profile.Bio, &profile.Avatar, &profile.IsActive, &profile.DisplayOrder,
-		&profile.CreatedAt, &profile.UpdatedAt,
+		&profile.Bio, &profile.Avatar, &profile.IsActive, &profile.Visibility, &profile.PasswordHash,
+		&profile.DisplayOrder, &profile.CreatedAt, &profile.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -81,14 +106,15 @@ func (r *ProfileRepository) GetByID(ctx context.Context, id int) (*models.Profil
 // GetBySlug retrieves a profile by slug (for public view)
 func (r *ProfileRepository) GetBySlug(ctx context.Context, slug string) (*models.Profile, error) {
 	query := `
-		SELECT id, user_id, slug, name, title, bio, avatar, is_active, display_order, created_at, updated_at
+		SELECT id, user_id, slug, name, title, bio, avatar, is_active, visibility, password_hash,
+			   display_order, created_at, updated_at
 		FROM profiles WHERE slug = $1 AND is_active = true
 	`
 	profile := &models.Profile{}
 	err := r.db.QueryRow(ctx, query, slug).Scan(
 		&profile.ID, &profile.UserID, &profile.Slug, &profile.Name, &profile.Title,
-		&profile.Bio, &profile.Avatar, &profile.IsActive, &profile.DisplayOrder,
-		&profile.CreatedAt, &profile.UpdatedAt,
+		&profile.Bio, &profile.Avatar, &profile.IsActive, &profile.Visibility, &profile.PasswordHash,
+		&profile.DisplayOrder, &profile.CreatedAt, &profile.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -103,7 +129,7 @@ func (r *ProfileRepository) GetBySlug(ctx context.Context, slug string) (*models
 func (r *ProfileRepository) GetByUserID(ctx context.Context, userID int) ([]models.ProfileWithStats, error) {
 	query := `
 		SELECT p.id, p.user_id, p.slug, p.name, p.title, p.bio, p.avatar, 
-			   p.is_active, p.display_order, p.created_at, p.updated_at,
+			   p.is_active, p.visibility, p.password_hash, p.display_order, p.created_at, p.updated_at,
 			   COUNT(DISTINCT l.id) as link_count,
 			   COALESCE(SUM(l.clicks), 0) as total_clicks
 		FROM profiles p
@@ -123,7 +149,7 @@ func (r *ProfileRepository) GetByUserID(ctx context.Context, userID int) ([]mode
 		var p models.ProfileWithStats
 		err := rows.Scan(
 			&p.ID, &p.UserID, &p.Slug, &p.Name, &p.Title, &p.Bio, &p.Avatar,
-			&p.IsActive, &p.DisplayOrder, &p.CreatedAt, &p.UpdatedAt,
+			&p.IsActive, &p.Visibility, &p.PasswordHash, &p.DisplayOrder, &p.CreatedAt, &p.UpdatedAt,
 			&p.LinkCount, &p.TotalClicks,
 		)
 		if err != nil {
@@ -134,17 +160,36 @@ func (r *ProfileRepository) GetByUserID(ctx context.Context, userID int) ([]mode
 	return profiles, nil
 }
 
-// Update updates a profile
+// Update updates a profile. A changed slug is recorded in the slug history
+// so the old one keeps redirecting and is quarantined.
 func (r *ProfileRepository) Update(ctx context.Context, profile *models.Profile) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var oldSlug string
+	var userID int
+	err = tx.QueryRow(ctx, "SELECT slug, user_id FROM profiles WHERE id = $1 FOR UPDATE", profile.ID).Scan(&oldSlug, &userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+
 	query := `
 		UPDATE profiles SET slug = $1, name = $2, title = $3, bio = $4, 
-			   avatar = $5, is_active = $6, display_order = $7, updated_at = $8
-		WHERE id = $9
+			   avatar = $5, is_active = $6, visibility = $7, password_hash = $8,
+			   display_order = $9, updated_at = $10
+		WHERE id = $11
 	`
 	now := time.Now()
-	result, err := r.db.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		profile.Slug, profile.Name, profile.Title, profile.Bio,
-		profile.Avatar, profile.IsActive, profile.DisplayOrder, now, profile.ID,
+		profile.Avatar, profile.IsActive, profile.Visibility, profile.PasswordHash,
+		profile.DisplayOrder, now, profile.ID,
 	)
 	if err != nil {
 		if isDuplicateError(err) {
@@ -152,22 +197,109 @@ func (r *ProfileRepository) Update(ctx context.Context, profile *models.Profile)
 		}
 		return err
 	}
+
+	if profile.Slug != oldSlug {
+		if err := releaseSlug(ctx, tx, oldSlug, &profile.ID, userID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM profile_slug_history WHERE slug = $1", profile.Slug); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetAvatar points a profile's avatar at an uploaded image
+func (r *ProfileRepository) SetAvatar(ctx context.Context, id int, avatar string) error {
+	result, err := r.db.Exec(ctx, "UPDATE profiles SET avatar = $1, updated_at = $2 WHERE id = $3", avatar, time.Now(), id)
+	if err != nil {
+		return err
+	}
 	if result.RowsAffected() == 0 {
 		return ErrNotFound
 	}
 	return nil
 }
 
-// Delete deletes a profile (cascade deletes theme, links, categories)
+// Delete deletes a profile (cascade deletes theme, links, categories). Its
+// slug is quarantined and its uploaded assets are left without a profile for
+// the asset collector to remove.
 func (r *ProfileRepository) Delete(ctx context.Context, id int) error {
-	result, err := r.db.Exec(ctx, "DELETE FROM profiles WHERE id = $1", id)
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	if result.RowsAffected() == 0 {
-		return ErrNotFound
+	defer tx.Rollback(ctx)
+
+	var slug string
+	var userID int
+	err = tx.QueryRow(ctx, "DELETE FROM profiles WHERE id = $1 RETURNING slug, user_id", id).Scan(&slug, &userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
 	}
-	return nil
+
+	if err := releaseSlug(ctx, tx, slug, nil, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// releaseSlug records a slug that a profile no longer uses
+func releaseSlug(ctx context.Context, tx pgx.Tx, slug string, profileID *int, userID int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO profile_slug_history (slug, profile_id, user_id, quarantine_until)
+		VALUES ($1, $2, $3, $4)
+	`, slug, profileID, userID, time.Now().Add(SlugQuarantine))
+	return err
+}
+
+// IsOnline reports whether a profile is shown to visitors: it is active and
+// its owner is not banned
+func (r *ProfileRepository) IsOnline(ctx context.Context, id int) (bool, error) {
+	var online bool
+	err := r.db.QueryRow(ctx, `
+		SELECT p.is_active AND u.is_active
+		FROM profiles p JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1
+	`, id).Scan(&online)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return online, err
+}
+
+// GetRedirectSlug returns the current slug of the active profile that
+// previously used slug
+func (r *ProfileRepository) GetRedirectSlug(ctx context.Context, slug string) (string, error) {
+	var current string
+	err := r.db.QueryRow(ctx, `
+		SELECT p.slug FROM profile_slug_history h
+		JOIN profiles p ON p.id = h.profile_id
+		WHERE h.slug = $1 AND p.is_active = true
+		ORDER BY h.released_at DESC
+		LIMIT 1
+	`, slug).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return current, nil
+}
+
+// RecordView records a view of a public profile page for analytics
+func (r *ProfileRepository) RecordView(ctx context.Context, view *models.ProfileView) error {
+	return r.db.QueryRow(ctx, `
+		INSERT INTO profile_views (profile_id, source, country, user_agent, referrer)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, viewed_at
+	`, view.ProfileID, view.Source, view.Country, view.UserAgent, view.Referrer).Scan(&view.ID, &view.ViewedAt)
 }
 
 // ExistsSlug checks if slug is taken
@@ -177,6 +309,60 @@ func (r *ProfileRepository) ExistsSlug(ctx context.Context, slug string) (bool,
 	return exists, err
 }
 
+// CheckSlug reports whether slug is used by a profile other than profileID,
+// whether a profile of another user has a slug with the same skeleton (see
+// slugpolicy.Skeleton), and whether such a slug was recently released by
+// another user
+func (r *ProfileRepository) CheckSlug(ctx context.Context, slug, skeleton string, profileID, userID int) (SlugConflict, error) {
+	var conflict SlugConflict
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			EXISTS(SELECT 1 FROM profiles WHERE slug = $1 AND id <> $3),
+			EXISTS(SELECT 1 FROM profiles WHERE slug_skeleton = $2 AND user_id <> $4),
+			EXISTS(SELECT 1 FROM profile_slug_history
+				WHERE slug_skeleton = $2 AND quarantine_until > NOW() AND user_id IS DISTINCT FROM $4)
+	`, slug, skeleton, profileID, userID).Scan(&conflict.Taken, &conflict.Similar, &conflict.Quarantined)
+	return conflict, err
+}
+
+// UnavailableSlugs returns which of the given slugs are taken or quarantined,
+// directly or through a slug with the same skeleton
+func (r *ProfileRepository) UnavailableSlugs(ctx context.Context, slugs, skeletons []string) (map[string]bool, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT slug, slug_skeleton FROM profiles
+		WHERE slug = ANY($1) OR slug_skeleton = ANY($2)
+		UNION ALL
+		SELECT slug, slug_skeleton FROM profile_slug_history
+		WHERE slug_skeleton = ANY($2) AND quarantine_until > NOW()
+	`, slugs, skeletons)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	takenSlugs := make(map[string]bool)
+	takenSkeletons := make(map[string]bool)
+	for rows.Next() {
+		var slug, skeleton string
+		if err := rows.Scan(&slug, &skeleton); err != nil {
+			return nil, err
+		}
+		takenSlugs[slug] = true
+		takenSkeletons[skeleton] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	unavailable := make(map[string]bool)
+	for i, slug := range slugs {
+		if takenSlugs[slug] || takenSkeletons[skeletons[i]] {
+			unavailable[slug] = true
+		}
+	}
+	return unavailable, nil
+}
+
 // BelongsToUser checks if profile belongs to user
 func (r *ProfileRepository) BelongsToUser(ctx context.Context, profileID, userID int) (bool, error) {
 	var exists bool
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Publications kept per profile, including the live one
+const maxPublications = 10
+
+type PublicationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPublicationRepository(db *pgxpool.Pool) *PublicationRepository {
+	return &PublicationRepository{db: db}
+}
+
+// GetLive retrieves the publication visitors of a profile see. ErrNotFound
+// means the profile was never published and is served as it is.
+func (r *PublicationRepository) GetLive(ctx context.Context, profileID int) (*models.ProfilePublication, error) {
+	query := `
+		SELECT id, profile_id, content, is_live, published_at
+		FROM profile_publications WHERE profile_id = $1 AND is_live
+	`
+	pub := &models.ProfilePublication{}
+	err := r.db.QueryRow(ctx, query, profileID).Scan(
+		&pub.ID, &pub.ProfileID, &pub.Content, &pub.IsLive, &pub.PublishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return pub, nil
+}
+
+// ListByProfileID returns the publications of a profile, newest first
+func (r *PublicationRepository) ListByProfileID(ctx context.Context, profileID int) ([]models.ProfilePublication, error) {
+	query := `
+		SELECT id, profile_id, content, is_live, published_at
+		FROM profile_publications WHERE profile_id = $1
+		ORDER BY id DESC
+	`
+	rows, err := r.db.Query(ctx, query, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pubs := []models.ProfilePublication{}
+	for rows.Next() {
+		var pub models.ProfilePublication
+		if err := rows.Scan(&pub.ID, &pub.ProfileID, &pub.Content, &pub.IsLive, &pub.PublishedAt); err != nil {
+			return nil, err
+		}
+		pubs = append(pubs, pub)
+	}
+	return pubs, nil
+}
+
+// Publish makes content the live publication of a profile, replacing the
+// current one in a single transaction, and drops the oldest publications
+// beyond the retention limit
+func (r *PublicationRepository) Publish(ctx context.Context, profileID int, content *models.PublishedContent) (*models.ProfilePublication, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locks the profile so concurrent publishes and rollbacks take turns
+	if err := lockProfile(ctx, tx, profileID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE profile_publications SET is_live = false WHERE profile_id = $1 AND is_live", profileID); err != nil {
+		return nil, err
+	}
+
+	pub := &models.ProfilePublication{ProfileID: profileID, Content: *content, IsLive: true}
+	query := `
+		INSERT INTO profile_publications (profile_id, content, is_live)
+		VALUES ($1, $2, true)
+		RETURNING id, published_at
+	`
+	if err := tx.QueryRow(ctx, query, profileID, content).Scan(&pub.ID, &pub.PublishedAt); err != nil {
+		return nil, err
+	}
+
+	query = `
+		DELETE FROM profile_publications
+		WHERE profile_id = $1 AND id NOT IN (
+			SELECT id FROM profile_publications WHERE profile_id = $1 ORDER BY id DESC LIMIT $2
+		)
+	`
+	if _, err := tx.Exec(ctx, query, profileID, maxPublications); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// Rollback makes the publication before the live one live again. It returns
+// ErrNotFound if there is none. The rolled back publication is kept, so a
+// rollback can be followed by another one further back.
+func (r *PublicationRepository) Rollback(ctx context.Context, profileID int) (*models.ProfilePublication, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockProfile(ctx, tx, profileID); err != nil {
+		return nil, err
+	}
+
+	var liveID int64
+	err = tx.QueryRow(ctx, "SELECT id FROM profile_publications WHERE profile_id = $1 AND is_live", profileID).Scan(&liveID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	query := `
+		SELECT id, profile_id, content, published_at
+		FROM profile_publications WHERE profile_id = $1 AND id < $2
+		ORDER BY id DESC LIMIT 1
+	`
+	pub := &models.ProfilePublication{IsLive: true}
+	err = tx.QueryRow(ctx, query, profileID, liveID).Scan(&pub.ID, &pub.ProfileID, &pub.Content, &pub.PublishedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE profile_publications SET is_live = false WHERE id = $1", liveID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE profile_publications SET is_live = true WHERE id = $1", pub.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// lockProfile locks a profile row for the rest of the transaction
+func lockProfile(ctx context.Context, tx pgx.Tx, profileID int) error {
+	var id int
+	err := tx.QueryRow(ctx, "SELECT id FROM profiles WHERE id = $1 FOR UPDATE", profileID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/pagination"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrReportClosed = errors.New("report already closed")
+
+type ReportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReportRepository(db *pgxpool.Pool) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// ReportFilter narrows a report listing. Zero values match everything.
+type ReportFilter struct {
+	Status     string
+	TargetType string
+	TargetID   int
+	Reason     string
+}
+
+// ReportSorts are the sort keys of report listings. Report IDs increase with
+// time, so they stand in for the creation time.
+var ReportSorts = map[string]pagination.Sort{
+	"created_at": {Column: "id", Type: "bigint"},
+}
+
+const reportColumns = `
+	r.id, r.target_type, r.target_id, r.profile_id, p.slug AS profile_slug, r.reason, r.details,
+	r.reporter_email, host(r.reporter_ip) AS reporter_ip, r.status, r.action, r.resolution_note,
+	r.resolved_by, r.resolved_at, r.created_at
+`
+
+// Create files a report. The reported profile or link must exist; the
+// report is linked to its profile.
+func (r *ReportRepository) Create(ctx context.Context, report *models.Report) error {
+	lookup := "SELECT id FROM profiles WHERE id = $1"
+	if report.TargetType == models.ReportTargetLink {
+		lookup = "SELECT profile_id FROM links WHERE id = $1"
+	}
+	var profileID int
+	if err := r.db.QueryRow(ctx, lookup, report.TargetID).Scan(&profileID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	report.ProfileID = &profileID
+
+	query := `
+		INSERT INTO reports (target_type, target_id, profile_id, reason, details, reporter_email, reporter_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::inet)
+		RETURNING id, status, action, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		report.TargetType, report.TargetID, report.ProfileID, report.Reason, report.Details,
+		report.ReporterEmail, report.ReporterIP,
+	).Scan(&report.ID, &report.Status, &report.Action, &report.CreatedAt)
+}
+
+// GetByID retrieves a report
+func (r *ReportRepository) GetByID(ctx context.Context, id int64) (*models.Report, error) {
+	query := `SELECT ` + reportColumns + `
+		FROM reports r
+		LEFT JOIN profiles p ON p.id = r.profile_id
+		WHERE r.id = $1
+	`
+	report, err := scanReport(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// List returns a page of the reports matching the filter
+func (r *ReportRepository) List(ctx context.Context, filter ReportFilter, page pagination.Params) (*pagination.Page[models.Report], error) {
+	var where pagination.Conditions
+	if filter.Status != "" {
+		where.Add("r.status = ?", filter.Status)
+	}
+	if filter.TargetType != "" {
+		where.Add("r.target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		where.Add("r.target_id = ?", filter.TargetID)
+	}
+	if filter.Reason != "" {
+		where.Add("r.reason = ?", filter.Reason)
+	}
+
+	inner := `SELECT ` + reportColumns + `
+		FROM reports r
+		LEFT JOIN profiles p ON p.id = r.profile_id
+		` + where.SQL()
+
+	var total int
+	if err := r.db.QueryRow(ctx, pagination.CountQuery(inner), where.Args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	query, args := page.Query(inner, ReportSorts, where.Args)
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []models.Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+
+	result := pagination.NewPage(page, reports, total, func(r models.Report) (interface{}, int64) {
+		return r.ID, r.ID
+	})
+	return &result, nil
+}
+
+// Close resolves or dismisses an open report. Only one of concurrent calls
+// succeeds; reports that are already closed fail with ErrReportClosed, so
+// the caller that closed the report is the one to take its action.
+func (r *ReportRepository) Close(ctx context.Context, id int64, status, action string, note *string, resolvedBy int) error {
+	query := `
+		UPDATE reports
+		SET status = $1, action = $2, resolution_note = $3, resolved_by = $4, resolved_at = $5
+		WHERE id = $6 AND status = 'open'
+	`
+	result, err := r.db.Exec(ctx, query, status, action, note, resolvedBy, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrReportClosed
+	}
+	return nil
+}
+
+// Reopen undoes Close, for reports whose action could not be taken
+func (r *ReportRepository) Reopen(ctx context.Context, id int64) error {
+	query := `
+		UPDATE reports
+		SET status = 'open', action = 'none', resolution_note = NULL, resolved_by = NULL, resolved_at = NULL
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func scanReport(row pgx.Row) (*models.Report, error) {
+	report := &models.Report{}
+	err := row.Scan(
+		&report.ID, &report.TargetType, &report.TargetID, &report.ProfileID, &report.ProfileSlug,
+		&report.Reason, &report.Details, &report.ReporterEmail, &report.ReporterIP, &report.Status,
+		&report.Action, &report.ResolutionNote, &report.ResolvedBy, &report.ResolvedAt, &report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
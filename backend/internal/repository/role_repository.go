@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RoleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRoleRepository(db *pgxpool.Pool) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// List returns all roles with their permissions
+func (r *RoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	query := `
+		SELECT r.id, r.name, r.description,
+			COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}'),
+			r.created_at
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		GROUP BY r.id
+		ORDER BY r.name
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.Permissions, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// GetByName retrieves a role with its permissions
+func (r *RoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	query := `
+		SELECT r.id, r.name, r.description,
+			COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}'),
+			r.created_at
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		WHERE r.name = $1
+		GROUP BY r.id
+	`
+	role := &models.Role{}
+	err := r.db.QueryRow(ctx, query, name).Scan(&role.ID, &role.Name, &role.Description, &role.Permissions, &role.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ThemePresetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewThemePresetRepository(db *pgxpool.Pool) *ThemePresetRepository {
+	return &ThemePresetRepository{db: db}
+}
+
+const themePresetColumns = "id, user_id, slug, name, settings, is_default, display_order, created_at"
+
+func scanThemePreset(row pgx.Row, preset *models.ThemePreset) error {
+	return row.Scan(
+		&preset.ID, &preset.UserID, &preset.Slug, &preset.Name, &preset.Settings,
+		&preset.IsDefault, &preset.DisplayOrder, &preset.CreatedAt,
+	)
+}
+
+// ListForUser returns the built-in presets followed by the user's own
+func (r *ThemePresetRepository) ListForUser(ctx context.Context, userID int) ([]models.ThemePreset, error) {
+	query := `SELECT ` + themePresetColumns + ` FROM theme_presets
+		WHERE user_id IS NULL OR user_id = $1
+		ORDER BY user_id NULLS FIRST, display_order, name`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presets := []models.ThemePreset{}
+	for rows.Next() {
+		var preset models.ThemePreset
+		if err := scanThemePreset(rows, &preset); err != nil {
+			return nil, err
+		}
+		presets = append(presets, preset)
+	}
+	return presets, nil
+}
+
+// GetForUser retrieves a built-in preset or one of the user's own
+func (r *ThemePresetRepository) GetForUser(ctx context.Context, id, userID int) (*models.ThemePreset, error) {
+	query := `SELECT ` + themePresetColumns + ` FROM theme_presets
+		WHERE id = $1 AND (user_id IS NULL OR user_id = $2)`
+	preset := &models.ThemePreset{}
+	if err := scanThemePreset(r.db.QueryRow(ctx, query, id, userID), preset); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return preset, nil
+}
+
+// CountByUser returns the number of presets a user saved
+func (r *ThemePresetRepository) CountByUser(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM theme_presets WHERE user_id = $1", userID).Scan(&count)
+	return count, err
+}
+
+// Create saves a personal preset
+func (r *ThemePresetRepository) Create(ctx context.Context, preset *models.ThemePreset) error {
+	query := `
+		INSERT INTO theme_presets (user_id, name, settings)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query, preset.UserID, preset.Name, preset.Settings).Scan(&preset.ID, &preset.CreatedAt)
+	if err != nil {
+		if isDuplicateError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete deletes one of the user's presets; built-in presets cannot be deleted
+func (r *ThemePresetRepository) Delete(ctx context.Context, id, userID int) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM theme_presets WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -24,7 +24,7 @@ func (r *ThemeRepository) GetByProfileID(ctx context.Context, profileID int) (*m
 		SELECT id, profile_id, bg_type, bg_value, button_style, button_color, text_color, font,
 			   layout, container_style, enable_animations, enable_glass_effect, shadow_intensity,
 			   boxed_enabled, boxed_outer_bg_type, boxed_outer_bg_value, boxed_container_bg,
-			   boxed_max_width, boxed_radius, boxed_shadow, created_at, updated_at
+			   boxed_max_width, boxed_radius, boxed_shadow, custom_css, custom_font_url, created_at, updated_at
 		FROM themes WHERE profile_id = $1
 	`
 	theme := &models.Theme{}
@@ -34,7 +34,7 @@ func (r *ThemeRepository) GetByProfileID(ctx context.Context, profileID int) (*m
 		&theme.Layout, &theme.ContainerStyle, &theme.EnableAnimations, &theme.EnableGlassEffect,
 		&theme.ShadowIntensity, &theme.BoxedEnabled, &theme.BoxedOuterBgType, &theme.BoxedOuterBgValue,
 		&theme.BoxedContainerBg, &theme.BoxedMaxWidth, &theme.BoxedRadius, &theme.BoxedShadow,
-		&theme.CreatedAt, &theme.UpdatedAt,
+		&theme.CustomCSS, &theme.CustomFontURL, &theme.CreatedAt, &theme.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -45,32 +45,83 @@ func (r *ThemeRepository) GetByProfileID(ctx context.Context, profileID int) (*m
 	return theme, nil
 }
 
-// Update updates a theme
-func (r *ThemeRepository) Update(ctx context.Context, theme *models.Theme) error {
+// Update updates a theme and records the result as a new version. source is
+// one of the models.ThemeSource constants.
+func (r *ThemeRepository) Update(ctx context.Context, theme *models.Theme, source string) error {
 	query := `
 		UPDATE themes SET 
 			bg_type = $1, bg_value = $2, button_style = $3, button_color = $4, text_color = $5,
 			font = $6, layout = $7, container_style = $8, enable_animations = $9, enable_glass_effect = $10,
 			shadow_intensity = $11, boxed_enabled = $12, boxed_outer_bg_type = $13, boxed_outer_bg_value = $14,
 			boxed_container_bg = $15, boxed_max_width = $16, boxed_radius = $17, boxed_shadow = $18,
-			updated_at = $19
-		WHERE profile_id = $20
+			custom_css = $19, custom_font_url = $20, updated_at = $21
+		WHERE profile_id = $22
 	`
 	now := time.Now()
-	result, err := r.db.Exec(ctx, query,
+	return r.change(ctx, theme.ProfileID, source, query,
 		theme.BgType, theme.BgValue, theme.ButtonStyle, theme.ButtonColor, theme.TextColor,
 		theme.Font, theme.Layout, theme.ContainerStyle, theme.EnableAnimations, theme.EnableGlassEffect,
 		theme.ShadowIntensity, theme.BoxedEnabled, theme.BoxedOuterBgType, theme.BoxedOuterBgValue,
 		theme.BoxedContainerBg, theme.BoxedMaxWidth, theme.BoxedRadius, theme.BoxedShadow,
-		now, theme.ProfileID,
+		theme.CustomCSS, theme.CustomFontURL, now, theme.ProfileID,
 	)
+}
+
+// SetBackgroundImage switches a profile's theme to an uploaded background image
+func (r *ThemeRepository) SetBackgroundImage(ctx context.Context, profileID int, url string) error {
+	query := "UPDATE themes SET bg_type = 'image', bg_value = $1, updated_at = $2 WHERE profile_id = $3"
+	return r.change(ctx, profileID, models.ThemeSourceUpload, query, url, time.Now(), profileID)
+}
+
+// SetCustomFont sets or, with nil, removes the uploaded font of a profile's
+// theme
+func (r *ThemeRepository) SetCustomFont(ctx context.Context, profileID int, url *string) error {
+	source := models.ThemeSourceUpload
+	if url == nil {
+		source = models.ThemeSourceUpdate
+	}
+	query := "UPDATE themes SET custom_font_url = $1, updated_at = $2 WHERE profile_id = $3"
+	return r.change(ctx, profileID, source, query, url, time.Now(), profileID)
+}
+
+// change runs an update of a profile's theme and records the result as a
+// new version
+func (r *ThemeRepository) change(ctx context.Context, profileID int, source, query string, args ...any) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, args...)
 	if err != nil {
 		return err
 	}
 	if result.RowsAffected() == 0 {
 		return ErrNotFound
 	}
-	return nil
+	if err := recordThemeVersion(ctx, tx, profileID, source); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertTheme creates the theme of a new profile
+func insertTheme(ctx context.Context, tx pgx.Tx, profileID int, s *models.ThemeSettings) error {
+	query := `
+		INSERT INTO themes (profile_id, bg_type, bg_value, button_style, button_color, text_color, font,
+			layout, container_style, enable_animations, enable_glass_effect, shadow_intensity,
+			boxed_enabled, boxed_outer_bg_type, boxed_outer_bg_value, boxed_container_bg,
+			boxed_max_width, boxed_radius, boxed_shadow, custom_css, custom_font_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+	`
+	_, err := tx.Exec(ctx, query,
+		profileID, s.BgType, s.BgValue, s.ButtonStyle, s.ButtonColor, s.TextColor, s.Font,
+		s.Layout, s.ContainerStyle, s.EnableAnimations, s.EnableGlassEffect, s.ShadowIntensity,
+		s.BoxedEnabled, s.BoxedOuterBgType, s.BoxedOuterBgValue, s.BoxedContainerBg,
+		s.BoxedMaxWidth, s.BoxedRadius, s.BoxedShadow, s.CustomCSS, s.CustomFontURL,
+	)
+	return err
 }
 
 // Create creates a new theme (usually called when creating profile)
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Versions kept per profile; older ones are dropped as new ones are added
+const maxThemeVersions = 50
+
+type ThemeVersionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewThemeVersionRepository(db *pgxpool.Pool) *ThemeVersionRepository {
+	return &ThemeVersionRepository{db: db}
+}
+
+// ListByProfileID returns the versions of a profile's theme, newest first
+func (r *ThemeVersionRepository) ListByProfileID(ctx context.Context, profileID int) ([]models.ThemeVersion, error) {
+	query := `
+		SELECT id, profile_id, version, settings, source, created_at
+		FROM theme_versions WHERE profile_id = $1
+		ORDER BY version DESC
+	`
+	rows, err := r.db.Query(ctx, query, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.ThemeVersion{}
+	for rows.Next() {
+		var v models.ThemeVersion
+		if err := rows.Scan(&v.ID, &v.ProfileID, &v.Version, &v.Settings, &v.Source, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// Get retrieves one version of a profile's theme
+func (r *ThemeVersionRepository) Get(ctx context.Context, profileID, version int) (*models.ThemeVersion, error) {
+	query := `
+		SELECT id, profile_id, version, settings, source, created_at
+		FROM theme_versions WHERE profile_id = $1 AND version = $2
+	`
+	v := &models.ThemeVersion{}
+	err := r.db.QueryRow(ctx, query, profileID, version).Scan(
+		&v.ID, &v.ProfileID, &v.Version, &v.Settings, &v.Source, &v.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// recordThemeVersion snapshots the current theme of a profile as a new
+// version, unless it equals the latest one, and applies the retention limit.
+// It must run in the transaction that changed the theme: the theme row stays
+// locked until commit, so concurrent changes get consecutive versions.
+func recordThemeVersion(ctx context.Context, tx pgx.Tx, profileID int, source string) error {
+	// Theme columns are named like the JSON fields of models.ThemeSettings
+	query := `
+		WITH latest AS (
+			SELECT version, settings FROM theme_versions
+			WHERE profile_id = $1 ORDER BY version DESC LIMIT 1
+		), snapshot AS (
+			SELECT to_jsonb(t) - 'id' - 'profile_id' - 'created_at' - 'updated_at' AS settings
+			FROM themes t WHERE t.profile_id = $1
+		)
+		INSERT INTO theme_versions (profile_id, version, settings, source)
+		SELECT $1, COALESCE((SELECT version FROM latest), 0) + 1, s.settings, $2
+		FROM snapshot s
+		WHERE NOT EXISTS (SELECT 1 FROM latest l WHERE l.settings = s.settings)
+	`
+	if _, err := tx.Exec(ctx, query, profileID, source); err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM theme_versions
+		WHERE profile_id = $1
+		  AND version <= (SELECT MAX(version) FROM theme_versions WHERE profile_id = $1) - $2
+	`
+	_, err := tx.Exec(ctx, query, profileID, maxThemeVersions)
+	return err
+}
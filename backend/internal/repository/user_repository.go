@@ -12,6 +12,8 @@ import (
 
 var ErrNotFound = errors.New("not found")
 var ErrDuplicate = errors.New("already exists")
+var ErrLastAdmin = errors.New("last active admin")
+var ErrUnknownRole = errors.New("unknown role")
 
 type UserRepository struct {
 	db *pgxpool.Pool
@@ -44,13 +46,14 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_verified, is_active, is_admin, created_at, updated_at
+		SELECT id, username, email, password_hash, is_verified, is_active, is_admin, is_premium,
+			(SELECT name FROM roles WHERE roles.id = users.role_id), token_version, created_at, updated_at
 		FROM users WHERE id = $1 AND is_active = true
 	`
 	user := &models.User{}
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.IsVerified, &user.IsActive, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsVerified, &user.IsActive, &user.IsAdmin, &user.IsPremium, &user.Role, &user.TokenVersion, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -64,13 +67,14 @@ func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, err
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_verified, is_active, is_admin, created_at, updated_at
+		SELECT id, username, email, password_hash, is_verified, is_active, is_admin, is_premium,
+			(SELECT name FROM roles WHERE roles.id = users.role_id), token_version, created_at, updated_at
 		FROM users WHERE email = $1
 	`
 	user := &models.User{}
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.IsVerified, &user.IsActive, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsVerified, &user.IsActive, &user.IsAdmin, &user.IsPremium, &user.Role, &user.TokenVersion, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -84,13 +88,14 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 // GetByUsername retrieves a user by username
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_verified, is_active, is_admin, created_at, updated_at
+		SELECT id, username, email, password_hash, is_verified, is_active, is_admin, is_premium,
+			(SELECT name FROM roles WHERE roles.id = users.role_id), token_version, created_at, updated_at
 		FROM users WHERE username = $1
 	`
 	user := &models.User{}
 	err := r.db.QueryRow(ctx, query, username).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.IsVerified, &user.IsActive, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsVerified, &user.IsActive, &user.IsAdmin, &user.IsPremium, &user.Role, &user.TokenVersion, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -112,11 +117,161 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	return err
 }
 
-// UpdatePassword updates user password
-func (r *UserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
-	query := `UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.Exec(ctx, query, passwordHash, time.Now(), id)
-	return err
+// AdminUpdate applies an admin's changes to a user's status in one
+// transaction and returns the user before and after. Taking admin rights
+// from, or disabling, the last active admin fails with ErrLastAdmin. The
+// sessions of disabled users are revoked.
+func (r *UserRepository) AdminUpdate(ctx context.Context, id int, req models.AdminUpdateUserRequest) (before, after *models.User, err error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the active admins first, in a fixed order, so concurrent
+	// demotions cannot both see another admin left
+	demoting := (req.IsAdmin != nil && !*req.IsAdmin) || (req.IsActive != nil && !*req.IsActive)
+	if demoting {
+		_, err = tx.Exec(ctx, "SELECT id FROM users WHERE is_admin AND is_active ORDER BY id FOR UPDATE")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	query := `
+		SELECT id, username, email, password_hash, is_verified, is_active, is_admin, is_premium,
+			(SELECT name FROM roles WHERE roles.id = users.role_id), token_version, created_at, updated_at
+		FROM users WHERE id = $1
+		FOR UPDATE
+	`
+	before = &models.User{}
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&before.ID, &before.Username, &before.Email, &before.PasswordHash,
+		&before.IsVerified, &before.IsActive, &before.IsAdmin, &before.IsPremium, &before.Role, &before.TokenVersion, &before.CreatedAt, &before.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+
+	u := *before
+	after = &u
+	if req.IsVerified != nil {
+		after.IsVerified = *req.IsVerified
+	}
+	if req.IsActive != nil {
+		after.IsActive = *req.IsActive
+	}
+	if req.IsAdmin != nil {
+		after.IsAdmin = *req.IsAdmin
+	}
+	if req.IsPremium != nil {
+		after.IsPremium = *req.IsPremium
+	}
+	if req.Role != nil {
+		after.Role = nil
+		if *req.Role != "" {
+			var name string
+			err = tx.QueryRow(ctx, "SELECT name FROM roles WHERE name = $1", *req.Role).Scan(&name)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return nil, nil, ErrUnknownRole
+				}
+				return nil, nil, err
+			}
+			after.Role = &name
+		}
+	}
+
+	if before.IsAdmin && before.IsActive && !(after.IsAdmin && after.IsActive) {
+		var others int
+		err = tx.QueryRow(ctx,
+			"SELECT COUNT(*) FROM users WHERE is_admin AND is_active AND id <> $1", id,
+		).Scan(&others)
+		if err != nil {
+			return nil, nil, err
+		}
+		if others == 0 {
+			return nil, nil, ErrLastAdmin
+		}
+	}
+
+	// Bans and loss of admin rights or a role invalidate issued access tokens
+	roleLost := before.Role != nil && (after.Role == nil || *after.Role != *before.Role)
+	if (before.IsActive && !after.IsActive) || (before.IsAdmin && !after.IsAdmin) || roleLost {
+		after.TokenVersion++
+	}
+
+	query = `
+		UPDATE users SET is_verified = $1, is_active = $2, is_admin = $3, is_premium = $4,
+			role_id = (SELECT id FROM roles WHERE name = $5), token_version = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING updated_at
+	`
+	err = tx.QueryRow(ctx, query,
+		after.IsVerified, after.IsActive, after.IsAdmin, after.IsPremium, after.Role, after.TokenVersion, id,
+	).Scan(&after.UpdatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Banned users are logged out everywhere
+	if before.IsActive && !after.IsActive {
+		if _, err := tx.Exec(ctx, "DELETE FROM sessions WHERE user_id = $1", id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// UpdatePassword updates user password and invalidates the user's access
+// tokens. It returns the new token version.
+func (r *UserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) (int, error) {
+	query := `
+		UPDATE users SET password_hash = $1, token_version = token_version + 1, updated_at = $2
+		WHERE id = $3
+		RETURNING token_version
+	`
+	var version int
+	err := r.db.QueryRow(ctx, query, passwordHash, time.Now(), id).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+// GetTokenState retrieves what decides whether a user's access tokens are
+// still valid
+func (r *UserRepository) GetTokenState(ctx context.Context, id int) (*models.TokenState, error) {
+	query := `
+		SELECT u.token_version, u.is_active, u.is_admin,
+			COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN role_permissions rp ON rp.role_id = u.role_id
+		WHERE u.id = $1
+		GROUP BY u.id
+	`
+	state := &models.TokenState{}
+	err := r.db.QueryRow(ctx, query, id).Scan(&state.Version, &state.IsActive, &state.IsAdmin, &state.Permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if state.IsAdmin {
+		state.Permissions = models.AllPermissions
+	}
+	return state, nil
 }
 
 // ExistsEmail checks if email exists
@@ -0,0 +1,199 @@
+package screening
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Frequently impersonated brands and the registrable domains they really use.
+// A brand name showing up anywhere else in a host name is suspicious.
+var protectedBrands = map[string][]string{
+	"paypal":         {"paypal.com", "paypal.me"},
+	"google":         {"google.com", "google.co.id", "googleusercontent.com", "googleapis.com", "google-analytics.com"},
+	"apple":          {"apple.com", "apple.co"},
+	"icloud":         {"icloud.com"},
+	"microsoft":      {"microsoft.com", "microsoftonline.com"},
+	"outlook":        {"outlook.com", "live.com"},
+	"facebook":       {"facebook.com", "fb.com", "fb.me"},
+	"instagram":      {"instagram.com"},
+	"whatsapp":       {"whatsapp.com", "whatsapp.net", "wa.me"},
+	"netflix":        {"netflix.com"},
+	"amazon":         {"amazon.com", "amazon.co.uk", "amazon.de", "amazon.co.jp", "amazon.sg"},
+	"tokopedia":      {"tokopedia.com", "tokopedia.link"},
+	"shopee":         {"shopee.co.id", "shopee.com", "shopee.sg"},
+	"gojek":          {"gojek.com"},
+	"gopay":          {"gopay.co.id"},
+	"klikbca":        {"klikbca.com"},
+	"bca":            {"bca.co.id", "klikbca.com"},
+	"steamcommunity": {"steamcommunity.com"},
+	"steampowered":   {"steampowered.com"},
+	"binance":        {"binance.com"},
+	"coinbase":       {"coinbase.com"},
+	"metamask":       {"metamask.io"},
+}
+
+// Brand names in a stable order so reasons come out deterministically
+var brandNames = func() []string {
+	names := make([]string, 0, len(protectedBrands))
+	for brand := range protectedBrands {
+		names = append(names, brand)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// Public URL shortener hosts. These are the only hosts the screener ever
+// sends requests to.
+var shortenerHosts = map[string]bool{
+	"bit.ly":      true,
+	"bitly.com":   true,
+	"tinyurl.com": true,
+	"t.co":        true,
+	"goo.gl":      true,
+	"ow.ly":       true,
+	"is.gd":       true,
+	"v.gd":        true,
+	"buff.ly":     true,
+	"cutt.ly":     true,
+	"rebrand.ly":  true,
+	"rb.gy":       true,
+	"shorturl.at": true,
+	"tiny.cc":     true,
+	"s.id":        true,
+	"t.ly":        true,
+	"bl.ink":      true,
+	"clck.ru":     true,
+	"qrco.de":     true,
+}
+
+// Characters that render like latin letters, folded before comparing a
+// domain label with brand names
+var confusables = map[rune]rune{
+	// Digits
+	'0': 'o', '1': 'l', '3': 'e', '4': 'a', '5': 's', '7': 't',
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	'ɡ': 'g', 'ӏ': 'l',
+	// Greek
+	'α': 'a', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x',
+}
+
+func isShortener(host string) bool {
+	return shortenerHosts[strings.TrimPrefix(host, "www.")]
+}
+
+// lookalikeReasons returns why host looks like it impersonates a protected
+// brand, if it does
+func lookalikeReasons(host string) []string {
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return nil
+	}
+	for _, official := range protectedBrands {
+		for _, domain := range official {
+			if registrable == domain {
+				return nil
+			}
+		}
+	}
+
+	unicodeHost, err := idna.Lookup.ToUnicode(host)
+	if err != nil {
+		unicodeHost = host
+	}
+
+	var reasons []string
+	for _, label := range strings.Split(unicodeHost, ".") {
+		if mixedScripts(label) {
+			reasons = append(reasons, "domain name mixes alphabets")
+			break
+		}
+	}
+
+	// The label registered by the site owner, e.g. "paypa1" in "paypa1.co.uk"
+	suffix, _ := publicsuffix.PublicSuffix(host)
+	ownLabel := strings.TrimSuffix(registrable, "."+suffix)
+	if u, err := idna.Lookup.ToUnicode(ownLabel); err == nil {
+		ownLabel = u
+	}
+	ownSkeleton := skeleton(ownLabel)
+
+	// Every word of every label, e.g. "paypal", "com", "secure", "login" in
+	// "paypal.com.secure-login.xyz"
+	words := strings.FieldsFunc(unicodeHost, func(r rune) bool { return r == '.' || r == '-' })
+
+	for _, brand := range brandNames {
+		switch {
+		case ownSkeleton == brand && ownLabel != brand:
+			reasons = append(reasons, "domain imitates "+brand+" with lookalike characters")
+		case len(brand) >= 6 && ownSkeleton != brand && editDistance(ownSkeleton, brand) == 1:
+			reasons = append(reasons, "domain is a misspelling of "+brand)
+		default:
+			for _, w := range words {
+				if skeleton(w) == brand {
+					reasons = append(reasons, "uses the name "+brand+" outside its official domains")
+					break
+				}
+			}
+		}
+	}
+	return reasons
+}
+
+// skeleton lowercases s and folds confusable characters and letter pairs
+// ("rn" for "m", "vv" for "w") to the latin letters they imitate
+func skeleton(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if folded, ok := confusables[r]; ok {
+			r = folded
+		}
+		b.WriteRune(r)
+	}
+	return strings.NewReplacer("rn", "m", "vv", "w").Replace(b.String())
+}
+
+// mixedScripts reports whether a label combines latin letters with letters of
+// another alphabet, the usual sign of a homograph attack
+func mixedScripts(label string) bool {
+	var latin, other bool
+	for _, r := range label {
+		switch {
+		case r < unicode.MaxASCII:
+			if unicode.IsLetter(r) {
+				latin = true
+			}
+		case unicode.Is(unicode.Cyrillic, r), unicode.Is(unicode.Greek, r), unicode.Is(unicode.Armenian, r):
+			other = true
+		}
+	}
+	return latin && other
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,143 @@
+package screening
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+)
+
+func TestLookalikeReasons(t *testing.T) {
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{"paypal.com", nil},
+		{"www.paypal.com", nil},
+		{"accounts.google.co.id", nil},
+		{"example.com", nil},
+		{"pay.example.com", nil},
+		{"paypa1.com", []string{"domain imitates paypal with lookalike characters"}},
+		{"paypa1.co.uk", []string{"domain imitates paypal with lookalike characters"}},
+		{"arnazon.com", []string{"domain imitates amazon with lookalike characters"}},
+		{"paypall.com", []string{"domain is a misspelling of paypal"}},
+		{"netflx.com", []string{"domain is a misspelling of netflix"}},
+		{"paypal.com.secure-login.xyz", []string{"uses the name paypal outside its official domains"}},
+		{"login-paypal.example.net", []string{"uses the name paypal outside its official domains"}},
+		// Cyrillic "а" and "р" in an otherwise latin label
+		{"xn--pypl-53dc.com", []string{
+			"domain name mixes alphabets",
+			"domain imitates paypal with lookalike characters",
+		}},
+		// Entirely cyrillic "рауре" is not mixed, but still folds to a brand
+		{"xn--80aa0cbo65f.com", []string{"domain imitates paypal with lookalike characters"}},
+		// Short brands are not matched by edit distance
+		{"bcb.co.id", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := lookalikeReasons(tt.host); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookalikeReasons(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"PayPal", "paypal"},
+		{"paypa1", "paypal"},
+		{"g00gle", "google"},
+		{"arnazon", "amazon"},
+		{"vvhatsapp", "whatsapp"},
+		{"рaypal", "paypal"},
+		{"αpple", "apple"},
+	}
+	for _, tt := range tests {
+		if got := skeleton(tt.in); got != tt.want {
+			t.Errorf("skeleton(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMixedScripts(t *testing.T) {
+	tests := []struct {
+		label string
+		want  bool
+	}{
+		{"paypal", false},
+		{"рaypal", true},
+		{"раура", false},
+		{"αpple", true},
+		{"bücher", false},
+		{"123", false},
+	}
+	for _, tt := range tests {
+		if got := mixedScripts(tt.label); got != tt.want {
+			t.Errorf("mixedScripts(%q) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"paypal", "paypal", 0},
+		{"paypal", "paypall", 1},
+		{"paypal", "paypl", 1},
+		{"paypal", "paypel", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestScreen(t *testing.T) {
+	s := New()
+	s.domains, s.patterns = parseBlocklist(strings.NewReader(
+		"# comment\n" +
+			"*.evil.example\n" +
+			"bad.test.\n" +
+			"re:/wp-admin/.*\\.php$\n" +
+			"re:([\n",
+	))
+
+	tests := []struct {
+		url     string
+		status  string
+		reasons []string
+	}{
+		{"https://example.com/", models.ScreeningClean, nil},
+		{"mailto:hello@example.com", models.ScreeningClean, nil},
+		{"https://evil.example/", models.ScreeningBlocked, []string{"domain evil.example is blocklisted"}},
+		{"https://login.evil.example/", models.ScreeningBlocked, []string{"domain evil.example is blocklisted"}},
+		{"https://bad.test/", models.ScreeningBlocked, []string{"domain bad.test is blocklisted"}},
+		{"https://notevil.example/", models.ScreeningClean, nil},
+		{"https://example.com/wp-admin/x.php", models.ScreeningBlocked, []string{"URL matches a blocklisted pattern"}},
+		{"http://192.0.2.1/login", models.ScreeningFlagged, []string{"destination is a raw IP address"}},
+		{"https://paypa1.com/", models.ScreeningFlagged, []string{"domain imitates paypal with lookalike characters"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			r := s.Screen(context.Background(), tt.url)
+			if r.Status != tt.status || !reflect.DeepEqual(r.Reasons, tt.reasons) {
+				t.Errorf("Screen(%q) = %s %q, want %s %q", tt.url, r.Status, r.Reasons, tt.status, tt.reasons)
+			}
+			if r.Held() != (tt.status != models.ScreeningClean) {
+				t.Errorf("Screen(%q).Held() = %v", tt.url, r.Held())
+			}
+		})
+	}
+}
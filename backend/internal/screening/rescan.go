@@ -0,0 +1,76 @@
+package screening
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+)
+
+// Links re-screened per batch; batches repeat until no link is due
+const rescanBatchSize = 200
+
+// Run re-screens existing links every interval until ctx is cancelled, so
+// that blocklist updates and redirects changed after saving are caught. A
+// changed blocklist file is reloaded before each pass.
+func (s *Screener) Run(ctx context.Context, links *repository.LinkRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reload(); err != nil {
+			log.Printf("URL screening: failed to reload blocklist: %v", err)
+		}
+		s.rescan(ctx, links, time.Now().Add(-interval))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rescan screens every link last screened before the given time
+func (s *Screener) rescan(ctx context.Context, links *repository.LinkRepository, before time.Time) {
+	screened, held := 0, 0
+	for ctx.Err() == nil {
+		batchStart := screened
+		batch, err := links.GetDueForScreening(ctx, before, rescanBatchSize)
+		if err != nil {
+			log.Printf("URL screening: failed to load links: %v", err)
+			return
+		}
+
+		for _, link := range batch {
+			// The draft URL, variants, targeting rules and the URL visitors
+			// are sent to while an older publication is live
+			urls := []string{link.URL}
+			if extra, err := links.GetDestinations(ctx, link.ID); err == nil {
+				urls = append(urls, extra...)
+			}
+
+			result := s.ScreenAll(ctx, urls)
+			if err := links.SetScreening(ctx, link.ID, result.Status, result.Reasons); err != nil {
+				log.Printf("URL screening: failed to update link %d: %v", link.ID, err)
+				continue
+			}
+			screened++
+			if result.Held() {
+				held++
+				log.Printf("URL screening: link %d %s (%s)", link.ID, result.Status, result.Reason())
+			}
+		}
+
+		// Stop on a short batch, or when nothing could be updated so the same
+		// links would come back forever
+		if len(batch) < rescanBatchSize || screened == batchStart {
+			break
+		}
+	}
+
+	if screened > 0 {
+		log.Printf("URL screening: rescanned %d links, %d held for review", screened, held)
+	}
+}
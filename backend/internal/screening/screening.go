@@ -0,0 +1,331 @@
+// Package screening checks link destinations for phishing and malware hosting.
+// A URL is checked against a local blocklist of domains and patterns and a set
+// of heuristics (lookalike domains, raw IP hosts, chains of URL shorteners).
+package screening
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+)
+
+// Limits for following URL shortener redirects
+const (
+	maxRedirectHops = 5
+	resolveTimeout  = 3 * time.Second
+)
+
+// Domains blocked even without a blocklist file: IP loggers that are only
+// ever used to de-anonymise visitors
+var defaultBlocklist = []string{
+	"grabify.link",
+	"iplogger.org",
+	"iplogger.com",
+	"iplogger.ru",
+	"2no.co",
+	"yip.su",
+	"blasze.com",
+}
+
+// Result is the outcome of screening one or more URLs. Status is one of
+// models.ScreeningClean, models.ScreeningFlagged or models.ScreeningBlocked.
+type Result struct {
+	Status  string
+	Reasons []string
+}
+
+// Held reports whether the screened link must be deactivated
+func (r Result) Held() bool {
+	return r.Status == models.ScreeningFlagged || r.Status == models.ScreeningBlocked
+}
+
+// Reason returns the first reason, or "" for a clean result
+func (r Result) Reason() string {
+	if len(r.Reasons) == 0 {
+		return ""
+	}
+	return r.Reasons[0]
+}
+
+func (r *Result) add(status, reason string) {
+	if severity(status) > severity(r.Status) {
+		r.Status = status
+	}
+	for _, existing := range r.Reasons {
+		if existing == reason {
+			return
+		}
+	}
+	r.Reasons = append(r.Reasons, reason)
+}
+
+func severity(status string) int {
+	switch status {
+	case models.ScreeningBlocked:
+		return 2
+	case models.ScreeningFlagged:
+		return 1
+	}
+	return 0
+}
+
+// Screener holds the blocklist and performs the checks. It is safe for
+// concurrent use.
+type Screener struct {
+	mu       sync.RWMutex
+	domains  map[string]bool
+	patterns []*regexp.Regexp
+	path     string
+	modTime  time.Time
+
+	client *http.Client
+}
+
+// New creates a screener with the built-in blocklist
+func New() *Screener {
+	s := &Screener{
+		client: &http.Client{
+			Timeout: resolveTimeout,
+			// Redirects are followed by hand so that every hop gets screened
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	s.domains, s.patterns = parseBlocklist(strings.NewReader(strings.Join(defaultBlocklist, "\n")))
+	return s
+}
+
+// LoadFile adds the entries of a blocklist file to the built-in list. One
+// entry per line: a domain (which also blocks its subdomains, a leading "*."
+// is accepted) or "re:" followed by a regular expression matched against the
+// whole URL. Blank lines and lines starting with "#" are ignored.
+func (s *Screener) LoadFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	domains, patterns := parseBlocklist(strings.NewReader(strings.Join(defaultBlocklist, "\n")))
+	fileDomains, filePatterns := parseBlocklist(f)
+	for d := range fileDomains {
+		domains[d] = true
+	}
+	patterns = append(patterns, filePatterns...)
+
+	s.mu.Lock()
+	s.domains, s.patterns = domains, patterns
+	s.path, s.modTime = path, info.ModTime()
+	s.mu.Unlock()
+	return nil
+}
+
+// Reload re-reads the blocklist file if it changed since it was loaded
+func (s *Screener) Reload() error {
+	s.mu.RLock()
+	path, modTime := s.path, s.modTime
+	s.mu.RUnlock()
+
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(modTime) {
+		return nil
+	}
+	return s.LoadFile(path)
+}
+
+func parseBlocklist(r io.Reader) (map[string]bool, []*regexp.Regexp) {
+	domains := make(map[string]bool)
+	var patterns []*regexp.Regexp
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if expr, ok := strings.CutPrefix(line, "re:"); ok {
+			// Invalid expressions are skipped rather than failing the whole file
+			if re, err := regexp.Compile(strings.TrimSpace(expr)); err == nil {
+				patterns = append(patterns, re)
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(strings.ToLower(line), "*.")
+		domains[strings.TrimSuffix(domain, ".")] = true
+	}
+	return domains, patterns
+}
+
+// Screen checks a normalised link URL. URL shorteners are followed (a few
+// hops at most) and every destination on the way is screened as well.
+func (s *Screener) Screen(ctx context.Context, rawURL string) Result {
+	var r Result
+	s.check(rawURL, &r)
+	if r.Status != models.ScreeningBlocked && isShortener(hostname(rawURL)) {
+		s.followShorteners(ctx, rawURL, &r)
+	}
+	if r.Status == "" {
+		r.Status = models.ScreeningClean
+	}
+	return r
+}
+
+// ScreenAll screens several URLs of one link and combines the results
+func (s *Screener) ScreenAll(ctx context.Context, urls []string) Result {
+	var combined Result
+	for _, u := range urls {
+		r := s.Screen(ctx, u)
+		for _, reason := range r.Reasons {
+			combined.add(r.Status, reason)
+		}
+	}
+	if combined.Status == "" {
+		combined.Status = models.ScreeningClean
+	}
+	return combined
+}
+
+// check runs the offline checks on a single URL
+func (s *Screener) check(rawURL string, r *Result) {
+	s.mu.RLock()
+	for _, re := range s.patterns {
+		if re.MatchString(rawURL) {
+			r.add(models.ScreeningBlocked, "URL matches a blocklisted pattern")
+			break
+		}
+	}
+	host := hostname(rawURL)
+	if domain := s.blockedDomain(host); domain != "" {
+		r.add(models.ScreeningBlocked, "domain "+domain+" is blocklisted")
+	}
+	s.mu.RUnlock()
+
+	if host == "" {
+		return
+	}
+	if net.ParseIP(host) != nil {
+		r.add(models.ScreeningFlagged, "destination is a raw IP address")
+		return
+	}
+	for _, reason := range lookalikeReasons(host) {
+		r.add(models.ScreeningFlagged, reason)
+	}
+}
+
+// blockedDomain returns the blocklist entry matching host or one of its
+// parent domains. Callers hold s.mu.
+func (s *Screener) blockedDomain(host string) string {
+	for host != "" {
+		if s.domains[host] {
+			return host
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return ""
+}
+
+// followShorteners resolves a chain of shortened URLs. Only hosts on the
+// shortener list are ever requested, so user input cannot make the server
+// reach arbitrary (e.g. internal) addresses.
+func (s *Screener) followShorteners(ctx context.Context, rawURL string, r *Result) {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	current := rawURL
+	shorteners := 0
+	for hop := 0; hop < maxRedirectHops; hop++ {
+		if !isShortener(hostname(current)) {
+			return
+		}
+		shorteners++
+		if shorteners > 1 {
+			r.add(models.ScreeningFlagged, "destination is hidden behind a chain of URL shorteners")
+		}
+
+		next, err := s.resolve(ctx, current)
+		if err != nil || next == "" {
+			// Unreachable or expired short links are not evidence of abuse
+			return
+		}
+		s.check(next, r)
+		if r.Status == models.ScreeningBlocked {
+			return
+		}
+		current = next
+	}
+	r.add(models.ScreeningFlagged, fmt.Sprintf("more than %d redirects", maxRedirectHops))
+}
+
+// resolve returns the redirect target of a short URL, or "" if it does not
+// redirect
+func (s *Screener) resolve(ctx context.Context, rawURL string) (string, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	var resp *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", "LinkMy-URLScreening/1.0")
+		resp, err = s.client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		// Some shorteners do not answer HEAD requests
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			break
+		}
+	}
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return "", nil
+	}
+	location, err := base.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", err
+	}
+	if location.Scheme != "http" && location.Scheme != "https" {
+		return "", nil
+	}
+	return location.String(), nil
+}
+
+// hostname returns the lowercased host of a web URL, or "" for other schemes
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
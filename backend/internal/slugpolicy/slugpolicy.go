@@ -0,0 +1,207 @@
+// Package slugpolicy decides which profile slugs may be claimed: the allowed
+// characters and length, reserved words, profanity and lookalikes of reserved
+// words.
+package slugpolicy
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Length limits; MaxLength matches the profiles.slug column
+const (
+	MinLength = 3
+	MaxLength = 50
+)
+
+// Error describes why a slug was rejected. Rule is a stable, machine-readable
+// identifier; Message is meant for humans.
+type Error struct {
+	Rule    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrRequired   = &Error{Rule: "required", Message: "Slug is required"}
+	ErrTooShort   = &Error{Rule: "min", Message: "Slug must be at least 3 characters"}
+	ErrTooLong    = &Error{Rule: "max", Message: "Slug must be at most 50 characters"}
+	ErrCharset    = &Error{Rule: "charset", Message: "Slug may only contain letters, digits, hyphens and underscores"}
+	ErrSeparators = &Error{Rule: "separators", Message: "Slug must start and end with a letter or digit and must not repeat - or _"}
+	ErrReserved   = &Error{Rule: "reserved", Message: "Slug is reserved"}
+	ErrLookalike  = &Error{Rule: "lookalike", Message: "Slug is too similar to a reserved name"}
+	ErrProfane    = &Error{Rule: "profanity", Message: "Slug contains inappropriate language"}
+)
+
+// Slugs used by routes, the product itself or that suggest official status
+var reserved = map[string]bool{
+	"about": true, "account": true, "accounts": true, "admin": true, "administrator": true,
+	"api": true, "app": true, "assets": true, "auth": true, "billing": true,
+	"blog": true, "click": true, "contact": true, "dashboard": true, "docs": true,
+	"edit": true, "embed": true, "explore": true, "favicon": true, "health": true,
+	"help": true, "home": true, "link": true, "linkmy": true, "links": true,
+	"login": true, "logout": true, "mail": true, "moderator": true, "new": true,
+	"null": true, "official": true, "password": true, "pricing": true, "privacy": true,
+	"profile": true, "profiles": true, "public": true, "register": true, "report": true,
+	"reset": true, "robots": true, "root": true, "security": true, "settings": true,
+	"signin": true, "signup": true, "sitemap": true, "slugs": true, "staff": true,
+	"static": true, "status": true, "support": true, "system": true, "terms": true,
+	"themes": true, "undefined": true, "user": true, "users": true, "verify": true,
+	"www": true,
+}
+
+// Words rejected anywhere in a slug, including leetspeak spellings. Kept to
+// words that do not occur inside common names.
+var profanity = []string{
+	"asshole", "bastard", "bitch", "faggot", "fuck", "nigger", "porn", "shit", "slut", "whore",
+	// Indonesian
+	"bangsat", "jancok", "kontol", "memek", "ngentot",
+}
+
+var (
+	charsetPattern    = regexp.MustCompile(`^[a-z0-9_-]+$`)
+	separatorsPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9]|[_-][a-z0-9])*$`)
+	cleanupPattern    = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Normalize folds raw to its canonical (trimmed, lowercase) form and checks
+// it against the policy. A non-nil error is always an *Error.
+func Normalize(raw string) (string, error) {
+	slug := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case slug == "":
+		return "", ErrRequired
+	case !charsetPattern.MatchString(slug):
+		return "", ErrCharset
+	case len(slug) < MinLength:
+		return "", ErrTooShort
+	case len(slug) > MaxLength:
+		return "", ErrTooLong
+	case !separatorsPattern.MatchString(slug):
+		return "", ErrSeparators
+	}
+
+	if reserved[slug] {
+		return "", ErrReserved
+	}
+	skel := confusableSkeleton(slug)
+	for word := range reserved {
+		if confusableSkeleton(word) == skel {
+			return "", ErrLookalike
+		}
+	}
+
+	folded := foldLeet(slug)
+	for _, word := range profanity {
+		if strings.Contains(folded, word) {
+			return "", ErrProfane
+		}
+	}
+	return slug, nil
+}
+
+// Skeleton reduces a slug to how it reads: separators are dropped and
+// digits standing in for letters are folded, so "john_doe", "j0hn-doe" and
+// "johndoe" share a skeleton. Letters are left alone, so real words such as
+// "modern" and "modem" never collide. Keep in sync with the slug_skeleton
+// column expressions.
+func Skeleton(slug string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(slug) {
+		switch r {
+		case '-', '_':
+			continue
+		case '0':
+			r = 'o'
+		case '1':
+			r = 'l'
+		case '3':
+			r = 'e'
+		case '4':
+			r = 'a'
+		case '5':
+			r = 's'
+		case '7':
+			r = 't'
+		case '8':
+			r = 'b'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// confusableSkeleton also folds letters that look alike ("i" and "l", "rn"
+// and "m", "vv" and "w"). That would make ordinary slugs collide, so it is
+// only used to keep slugs from passing for reserved words.
+func confusableSkeleton(slug string) string {
+	skel := strings.ReplaceAll(Skeleton(slug), "i", "l")
+	return strings.NewReplacer("rn", "m", "vv", "w").Replace(skel)
+}
+
+// foldLeet undoes digit-for-letter spellings for the profanity check
+func foldLeet(slug string) string {
+	return strings.NewReplacer(
+		"-", "", "_", "",
+		"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b",
+	).Replace(slug)
+}
+
+// Suggest returns up to n alternatives to raw that satisfy the policy and
+// for which taken reports false. taken receives all candidates at once and
+// returns the ones that are unavailable.
+func Suggest(raw string, n int, taken func(candidates []string) map[string]bool) []string {
+	base := strings.Trim(cleanupPattern.ReplaceAllString(strings.ToLower(raw), "-"), "-")
+	if len(base) > MaxLength-4 {
+		base = strings.TrimRight(base[:MaxLength-4], "-")
+	}
+	if base == "" {
+		base = "my"
+	}
+	// Variations of reserved or offensive words are not worth offering
+	if _, err := Normalize(base); err == ErrReserved || err == ErrLookalike || err == ErrProfane {
+		return []string{}
+	}
+
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(slug string) {
+		if seen[slug] {
+			return
+		}
+		seen[slug] = true
+		if _, err := Normalize(slug); err == nil {
+			candidates = append(candidates, slug)
+		}
+	}
+
+	add(base)
+	separator := ""
+	if strings.Contains(base, "-") {
+		separator = "-"
+	}
+	for _, suffix := range []string{"hq", "page", "links", "id"} {
+		add(base + separator + suffix)
+	}
+	add("the" + base)
+	add("its" + base)
+	for i := 1; i <= 20; i++ {
+		add(base + strconv.Itoa(i))
+	}
+
+	unavailable := taken(candidates)
+	suggestions := make([]string, 0, n)
+	for _, slug := range candidates {
+		if len(suggestions) == n {
+			break
+		}
+		if !unavailable[slug] {
+			suggestions = append(suggestions, slug)
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,217 @@
+package slugpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		err  *Error
+	}{
+		{raw: "john-doe", want: "john-doe"},
+		{raw: "  John_Doe ", want: "john_doe"},
+		{raw: "abc", want: "abc"},
+		{raw: "a1b2c3", want: "a1b2c3"},
+		{raw: strings.Repeat("a", MaxLength), want: strings.Repeat("a", MaxLength)},
+		{raw: "   ", err: ErrRequired},
+		{raw: "ab", err: ErrTooShort},
+		{raw: strings.Repeat("a", MaxLength+1), err: ErrTooLong},
+		{raw: "john.doe", err: ErrCharset},
+		{raw: "jöhn", err: ErrCharset},
+		{raw: "john doe", err: ErrCharset},
+		{raw: "-john", err: ErrSeparators},
+		{raw: "john_", err: ErrSeparators},
+		{raw: "john--doe", err: ErrSeparators},
+		{raw: "john-_doe", err: ErrSeparators},
+		{raw: "admin", err: ErrReserved},
+		{raw: "API", err: ErrReserved},
+		{raw: "adm1n", err: ErrLookalike},
+		{raw: "log-in", err: ErrLookalike},
+		{raw: "supp0rt", err: ErrLookalike},
+		{raw: "linkrny", err: ErrLookalike},
+		{raw: "vvww", err: ErrLookalike},
+		{raw: "sh1t-happens", err: ErrProfane},
+		{raw: "f-u-c-k", err: ErrProfane},
+		{raw: "scunthorpe", want: "scunthorpe"},
+		{raw: "mill", want: "mill"},
+		{raw: "modern", want: "modern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Normalize(tt.raw)
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("Normalize(%q) = %q, %v, want %v", tt.raw, got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Normalize(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	tests := []struct {
+		slug string
+		want string
+	}{
+		{"john_doe", "johndoe"},
+		{"j0hn-doe", "johndoe"},
+		{"JohnDoe", "johndoe"},
+		{"adm1n", "admln"},
+		{"b4s3-8", "baseb"},
+		{"57", "st"},
+		// Letters are never folded, so real words stay apart
+		{"mail", "mail"},
+		{"mall", "mall"},
+		{"modern", "modern"},
+		{"modem", "modem"},
+		{"vvhatsapp", "vvhatsapp"},
+	}
+	for _, tt := range tests {
+		if got := Skeleton(tt.slug); got != tt.want {
+			t.Errorf("Skeleton(%q) = %q, want %q", tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestConfusableSkeleton(t *testing.T) {
+	tests := []struct {
+		slug string
+		want string
+	}{
+		{"admin", "admln"},
+		{"adm1n", "admln"},
+		{"log-in", "logln"},
+		{"arnazon", "amazon"},
+		{"vvhatsapp", "whatsapp"},
+		{"mail", "mall"},
+	}
+	for _, tt := range tests {
+		if got := confusableSkeleton(tt.slug); got != tt.want {
+			t.Errorf("confusableSkeleton(%q) = %q, want %q", tt.slug, got, tt.want)
+		}
+	}
+}
+
+// The slug_skeleton columns are generated by the database, so their
+// expression must fold slugs exactly like Skeleton
+var skeletonExpr = regexp.MustCompile(`translate\(lower\(slug\), '([^']*)', '([^']*)'\)`)
+
+func TestSkeletonMatchesSQL(t *testing.T) {
+	files, err := filepath.Glob("../database/migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+
+	// Every slug of up to four characters over the folded characters, the
+	// characters they fold to and a few others
+	alphabet := []rune("0134578i-_rnvmwlaeostbx")
+	corpus := []string{""}
+	for n := 0; n < 4; n++ {
+		for _, s := range corpus[len(corpus)-pow(len(alphabet), n):] {
+			for _, r := range alphabet {
+				corpus = append(corpus, s+string(r))
+			}
+		}
+	}
+	for word := range reserved {
+		corpus = append(corpus, word, strings.ToUpper(word))
+	}
+
+	found := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range skeletonExpr.FindAllStringSubmatch(string(data), -1) {
+			found++
+			for _, slug := range corpus {
+				sql := strings.ToLower(slug)
+				sql = translate(sql, m[1], m[2])
+				if got := Skeleton(slug); got != sql {
+					t.Fatalf("%s: skeleton of %q is %q in SQL, Skeleton returns %q", filepath.Base(file), slug, sql, got)
+				}
+			}
+		}
+	}
+	// 007 (profiles) and 008 (profile_slug_history)
+	if found < 2 {
+		t.Errorf("found %d slug_skeleton expressions, want at least 2", found)
+	}
+}
+
+// translate behaves like PostgreSQL's translate(): characters of from are
+// replaced by the character at the same position in to, or removed when to
+// is shorter
+func translate(s, from, to string) string {
+	fromRunes, toRunes := []rune(from), []rune(to)
+	var b strings.Builder
+	for _, r := range s {
+		i := 0
+		for i < len(fromRunes) && fromRunes[i] != r {
+			i++
+		}
+		switch {
+		case i == len(fromRunes):
+			b.WriteRune(r)
+		case i < len(toRunes):
+			b.WriteRune(toRunes[i])
+		}
+	}
+	return b.String()
+}
+
+func pow(base, exp int) int {
+	result := 1
+	for i := 0; i < exp; i++ {
+		result *= base
+	}
+	return result
+}
+
+func TestSuggest(t *testing.T) {
+	none := func([]string) map[string]bool { return nil }
+
+	tests := []struct {
+		name  string
+		raw   string
+		n     int
+		taken func([]string) map[string]bool
+		want  []string
+	}{
+		{"available", "John Doe", 3, none, []string{"john-doe", "john-doe-hq", "john-doe-page"}},
+		{"no separator", "jane", 2, none, []string{"jane", "janehq"}},
+		{
+			name: "taken",
+			raw:  "jane",
+			n:    3,
+			taken: func(candidates []string) map[string]bool {
+				return map[string]bool{"jane": true, "janehq": true}
+			},
+			want: []string{"janepage", "janelinks", "janeid"},
+		},
+		{"empty", "!!!", 2, none, []string{"myhq", "mypage"}},
+		{"reserved", "admin", 3, none, []string{}},
+		{"lookalike", "adm1n", 3, none, []string{}},
+		{"profane", "shit", 3, none, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Suggest(tt.raw, tt.n, tt.taken)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest(%q, %d) = %q, want %q", tt.raw, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/repository"
+)
+
+// How long a replaced avatar or background is kept before it is removed, so
+// pages and previews cached with the old URL keep working for a while.
+// Assets of deleted profiles are removed right away.
+const unusedAssetGrace = 7 * 24 * time.Hour
+
+const collectBatchSize = 200
+
+// Collector removes stored assets that no profile uses anymore
+type Collector struct {
+	store  Storage
+	assets *repository.AssetRepository
+	wake   chan struct{}
+}
+
+func NewCollector(store Storage, assets *repository.AssetRepository) *Collector {
+	return &Collector{store: store, assets: assets, wake: make(chan struct{}, 1)}
+}
+
+// Trigger asks a running collector to collect now, e.g. after a profile was
+// deleted. It never blocks.
+func (c *Collector) Trigger() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run collects orphaned assets every interval and when triggered, until ctx
+// is cancelled
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.collect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.wake:
+		}
+	}
+}
+
+// collect removes every orphaned asset. The row goes first: if the file
+// cannot be removed it is leaked rather than leaving a row without a file.
+func (c *Collector) collect(ctx context.Context) {
+	removed := 0
+	for ctx.Err() == nil {
+		unusedBefore := time.Now().Add(-unusedAssetGrace)
+		batchStart := removed
+		batch, err := c.assets.ListOrphaned(ctx, unusedBefore, collectBatchSize)
+		if err != nil {
+			log.Printf("Asset GC: failed to load orphaned assets: %v", err)
+			return
+		}
+
+		for _, asset := range batch {
+			// Skips assets taken into use since they were listed
+			deleted, err := c.assets.DeleteOrphaned(ctx, asset.ID, unusedBefore)
+			if err != nil {
+				log.Printf("Asset GC: failed to delete asset %d: %v", asset.ID, err)
+				continue
+			}
+			if !deleted {
+				continue
+			}
+			if err := c.store.Delete(ctx, asset.StorageKey); err != nil {
+				log.Printf("Asset GC: failed to remove %s: %v", asset.StorageKey, err)
+			}
+			removed++
+		}
+
+		// Stop on a short batch, or when nothing could be removed so the same
+		// assets would come back forever
+		if len(batch) < collectBatchSize || removed == batchStart {
+			break
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("Asset GC: removed %d orphaned assets", removed)
+	}
+}
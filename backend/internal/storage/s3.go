@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible backend (AWS S3, MinIO, Cloudflare
+// R2, ...)
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool   // Address the bucket as endpoint/bucket instead of bucket.endpoint (MinIO)
+	PublicURL string // Public URL of the bucket; defaults to its endpoint URL
+}
+
+// S3 stores assets in an S3-compatible bucket. Requests are signed with AWS
+// Signature Version 4.
+type S3 struct {
+	cfg       S3Config
+	bucketURL *url.URL
+	publicURL string
+	signer    signer
+	client    *http.Client
+}
+
+// Longest lifetime of a presigned URL accepted by S3
+const maxPresignTTL = 7 * 24 * time.Hour
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("s3 storage needs an endpoint, bucket and credentials")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	bucketURL := *endpoint
+	if cfg.PathStyle {
+		bucketURL.Path += "/" + cfg.Bucket
+	} else {
+		bucketURL.Host = cfg.Bucket + "." + endpoint.Host
+	}
+
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		publicURL = bucketURL.String()
+	}
+
+	return &S3{
+		cfg:       cfg,
+		bucketURL: &bucketURL,
+		publicURL: strings.TrimRight(publicURL, "/") + "/",
+		signer:    signer{accessKey: cfg.AccessKey, secretKey: cfg.SecretKey, region: cfg.Region, service: "s3"},
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	// The payload is hashed for the signature, so it is read into memory;
+	// assets are small
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return fmt.Errorf("s3 put %s: read %d bytes, expected %d", key, len(data), size)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("put", key, resp)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	defer resp.Body.Close()
+	return nil, responseError("get", key, resp)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 answers 204 whether or not the object existed; some stand-ins send 404
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError("delete", key, resp)
+	}
+	return nil
+}
+
+func (s *S3) URL(key string) string {
+	return s.publicURL + key
+}
+
+// SignedURL returns a presigned GET URL, which also works for private buckets
+func (s *S3) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	if ttl <= 0 || ttl > maxPresignTTL {
+		ttl = maxPresignTTL
+	}
+	u := s.objectURL(key)
+	s.signer.presign(u, time.Now(), ttl)
+	return u.String(), nil
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.bucketURL
+	u.Path += "/" + key
+	return &u
+}
+
+// newRequest builds a request for key signed in the Authorization header
+func (s *S3) newRequest(ctx context.Context, method, key string, payload []byte) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(payload))
+	if payload == nil {
+		req.Body = http.NoBody
+	}
+
+	sum := sha256.Sum256(payload)
+	s.sign(req, hex.EncodeToString(sum[:]), time.Now())
+	return req, nil
+}
+
+// sign adds the SigV4 headers and Authorization header to req
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	req.Header.Set("Authorization", s.signer.authorization(req.Method, req.URL, headers, payloadHash, now))
+}
+
+// Format of the X-Amz-Date header
+const amzDateFormat = "20060102T150405Z"
+
+// signer computes AWS Signature Version 4 signatures for one service
+type signer struct {
+	accessKey string
+	secretKey string
+	region    string
+	service   string
+}
+
+// authorization returns the Authorization header of a request signing the
+// given headers, which have lower-case names
+func (s signer) authorization(method string, u *url.URL, headers map[string]string, payloadHash string, now time.Time) string {
+	canonicalRequest, signedHeaders := s.canonicalRequest(method, u, headers, payloadHash)
+	signature := s.signature(now, s.stringToSign(now, canonicalRequest))
+	return fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, s.scope(now), signedHeaders, signature)
+}
+
+// presign adds the query parameters authorizing a GET of u for ttl
+func (s signer) presign(u *url.URL, now time.Time, ttl time.Duration) {
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.UTC().Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = canonicalQuery(query)
+
+	canonicalRequest, _ := s.canonicalRequest(http.MethodGet, u, map[string]string{"host": u.Host}, "UNSIGNED-PAYLOAD")
+	u.RawQuery += "&X-Amz-Signature=" + s.signature(now, s.stringToSign(now, canonicalRequest))
+}
+
+func (s signer) canonicalRequest(method string, u *url.URL, headers map[string]string, payloadHash string) (canonical, signedHeaders string) {
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(headers)
+	canonical = strings.Join([]string{
+		method,
+		canonicalURI(u),
+		canonicalQuery(u.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	return canonical, signedHeaders
+}
+
+func (s signer) stringToSign(now time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	return "AWS4-HMAC-SHA256\n" + now.UTC().Format(amzDateFormat) + "\n" + s.scope(now) + "\n" + hex.EncodeToString(hash[:])
+}
+
+func (s signer) scope(now time.Time) string {
+	return now.UTC().Format("20060102") + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+func (s signer) signature(now time.Time, stringToSign string) string {
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.UTC().Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalizeHeaders(headers map[string]string) (canonical, signed string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalURI URI-encodes each path segment the way S3 expects
+func canonicalURI(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// responseError turns an error response into an error, including the S3
+// error code when there is one
+func responseError(op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	code := ""
+	if start := bytes.Index(body, []byte("<Code>")); start >= 0 {
+		if end := bytes.Index(body[start:], []byte("</Code>")); end >= 0 {
+			code = ": " + string(body[start+len("<Code>"):start+end])
+		}
+	}
+	return fmt.Errorf("s3 %s %s: %s%s", op, key, resp.Status, code)
+}
@@ -0,0 +1,61 @@
+// Package storage stores user assets such as avatars and background images.
+// Backends are addressed by slash-separated keys like "avatars/12/ab34.jpg".
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("asset not found")
+	ErrInvalidKey = errors.New("invalid asset key")
+)
+
+// Storage is implemented by every storage backend
+type Storage interface {
+	// Put stores r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; missing objects are not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of key
+	URL(key string) string
+	// SignedURL returns a URL granting read access to key for ttl, even when
+	// the backend is not publicly readable
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// ValidKey reports whether key is a clean relative path without traversal
+func ValidKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") && path.Clean(key) == key &&
+		!strings.HasPrefix(key, "../") && key != ".." && !strings.Contains(key, "\\")
+}
+
+// KeyFromURL returns the key of an asset URL produced by s, or false if the
+// URL does not belong to s
+func KeyFromURL(s Storage, u string) (string, bool) {
+	key, ok := strings.CutPrefix(u, s.URL(""))
+	if !ok || !ValidKey(key) {
+		return "", false
+	}
+	return key, true
+}
+
+// KeyFromRef returns the key of an asset referenced either by a URL produced
+// by s or by its bare key, as profiles from before uploads store their avatar
+func KeyFromRef(s Storage, ref string) (string, bool) {
+	if key, ok := KeyFromURL(s, ref); ok {
+		return key, true
+	}
+	if strings.Contains(ref, "://") {
+		return "", false
+	}
+	key := strings.TrimPrefix(ref, "/")
+	return key, ValidKey(key)
+}
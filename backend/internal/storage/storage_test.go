@@ -0,0 +1,30 @@
+package storage
+
+import "testing"
+
+func TestKeyFromRef(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "https://linkmy.io/assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref    string
+		key    string
+		wantOK bool
+	}{
+		{"https://linkmy.io/assets/avatars/12/ab34.jpg", "avatars/12/ab34.jpg", true},
+		{"avatars/12/ab34.jpg", "avatars/12/ab34.jpg", true},
+		{"/photo.png", "photo.png", true},
+		{"https://cdn.example.com/avatars/12/ab34.jpg", "", false},
+		{"https://linkmy.io/assets/../config.env", "", false},
+		{"../config.env", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		key, ok := KeyFromRef(local, tt.ref)
+		if ok != tt.wantOK || (ok && key != tt.key) {
+			t.Errorf("KeyFromRef(%q) = %q, %v, want %q, %v", tt.ref, key, ok, tt.key, tt.wantOK)
+		}
+	}
+}
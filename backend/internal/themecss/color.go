@@ -0,0 +1,164 @@
+package themecss
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-f]{3,4}|[0-9a-f]{6}|[0-9a-f]{8})$`)
+
+// CSS named colors
+var namedColors = map[string]bool{}
+
+func init() {
+	for _, name := range strings.Fields(`
+		transparent aliceblue antiquewhite aqua aquamarine azure beige bisque black
+		blanchedalmond blue blueviolet brown burlywood cadetblue chartreuse chocolate
+		coral cornflowerblue cornsilk crimson cyan darkblue darkcyan darkgoldenrod
+		darkgray darkgreen darkgrey darkkhaki darkmagenta darkolivegreen darkorange
+		darkorchid darkred darksalmon darkseagreen darkslateblue darkslategray
+		darkslategrey darkturquoise darkviolet deeppink deepskyblue dimgray dimgrey
+		dodgerblue firebrick floralwhite forestgreen fuchsia gainsboro ghostwhite gold
+		goldenrod gray green greenyellow grey honeydew hotpink indianred indigo ivory
+		khaki lavender lavenderblush lawngreen lemonchiffon lightblue lightcoral
+		lightcyan lightgoldenrodyellow lightgray lightgreen lightgrey lightpink
+		lightsalmon lightseagreen lightskyblue lightslategray lightslategrey
+		lightsteelblue lightyellow lime limegreen linen magenta maroon
+		mediumaquamarine mediumblue mediumorchid mediumpurple mediumseagreen
+		mediumslateblue mediumspringgreen mediumturquoise mediumvioletred
+		midnightblue mintcream mistyrose moccasin navajowhite navy oldlace olive
+		olivedrab orange orangered orchid palegoldenrod palegreen paleturquoise
+		palevioletred papayawhip peachpuff peru pink plum powderblue purple
+		rebeccapurple red rosybrown royalblue saddlebrown salmon sandybrown seagreen
+		seashell sienna silver skyblue slateblue slategray slategrey snow springgreen
+		steelblue tan teal thistle tomato turquoise violet wheat white whitesmoke
+		yellow yellowgreen`) {
+		namedColors[name] = true
+	}
+}
+
+// ParseColor parses a hex, rgb()/rgba(), hsl()/hsla() or named color and
+// returns it in canonical form
+func ParseColor(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if hexColorPattern.MatchString(v) || namedColors[v] {
+		return v, true
+	}
+
+	name, args, ok := splitFunc(v)
+	if !ok {
+		return "", false
+	}
+	switch name {
+	case "rgb", "rgba":
+		return parseRGB(args)
+	case "hsl", "hsla":
+		return parseHSL(args)
+	}
+	return "", false
+}
+
+// splitFunc splits "name(args)" into the name and its arguments, accepting
+// both comma and space separated syntax ("rgb(1, 2, 3)", "rgb(1 2 3 / 50%)")
+func splitFunc(v string) (string, []string, bool) {
+	open := strings.IndexByte(v, '(')
+	if open <= 0 || !strings.HasSuffix(v, ")") {
+		return "", nil, false
+	}
+	name, inner := v[:open], v[open+1:len(v)-1]
+	if strings.ContainsAny(inner, "()") {
+		return "", nil, false
+	}
+
+	var args []string
+	if strings.Contains(inner, ",") {
+		for _, arg := range strings.Split(inner, ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+	} else {
+		color, alpha, hasAlpha := strings.Cut(inner, "/")
+		args = strings.Fields(color)
+		if hasAlpha {
+			args = append(args, strings.TrimSpace(alpha))
+		}
+	}
+	return name, args, true
+}
+
+func parseRGB(args []string) (string, bool) {
+	if len(args) != 3 && len(args) != 4 {
+		return "", false
+	}
+	channels := make([]string, 3)
+	for i := range channels {
+		n, percent, ok := parseNumber(args[i])
+		if !ok || n < 0 || (percent && n > 100) || (!percent && n > 255) {
+			return "", false
+		}
+		channels[i] = formatNumber(n, percent)
+	}
+	if len(args) == 3 {
+		return "rgb(" + strings.Join(channels, ", ") + ")", true
+	}
+	alpha, ok := parseAlpha(args[3])
+	if !ok {
+		return "", false
+	}
+	return "rgba(" + strings.Join(channels, ", ") + ", " + alpha + ")", true
+}
+
+func parseHSL(args []string) (string, bool) {
+	if len(args) != 3 && len(args) != 4 {
+		return "", false
+	}
+	hue, ok := parseAngle(args[0], true)
+	if !ok {
+		return "", false
+	}
+	parts := []string{hue}
+	for _, arg := range args[1:3] {
+		n, percent, ok := parseNumber(arg)
+		if !ok || !percent || n < 0 || n > 100 {
+			return "", false
+		}
+		parts = append(parts, formatNumber(n, true))
+	}
+	if len(args) == 3 {
+		return "hsl(" + strings.Join(parts, ", ") + ")", true
+	}
+	alpha, ok := parseAlpha(args[3])
+	if !ok {
+		return "", false
+	}
+	return "hsla(" + strings.Join(parts, ", ") + ", " + alpha + ")", true
+}
+
+func parseAlpha(v string) (string, bool) {
+	n, percent, ok := parseNumber(v)
+	if !ok || n < 0 || (percent && n > 100) || (!percent && n > 1) {
+		return "", false
+	}
+	return formatNumber(n, percent), true
+}
+
+// parseNumber parses a plain number or a percentage
+func parseNumber(v string) (n float64, percent bool, ok bool) {
+	v, percent = strings.CutSuffix(v, "%")
+	if v == "" || strings.ContainsAny(v, "eE+") {
+		return 0, false, false
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false, false
+	}
+	return n, percent, true
+}
+
+func formatNumber(n float64, percent bool) string {
+	s := strconv.FormatFloat(n, 'f', -1, 64)
+	if percent {
+		s += "%"
+	}
+	return s
+}
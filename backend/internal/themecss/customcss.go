@@ -0,0 +1,318 @@
+package themecss
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits of custom CSS
+const (
+	MaxCustomCSSLength = 10000
+	maxCustomCSSValue  = 500
+)
+
+// Selector every custom rule is scoped under, so custom CSS only styles the
+// page content
+const customCSSScope = "main"
+
+// Properties custom CSS may set. Anything that can place content over the
+// page (position, z-index, content) or load resources is left out.
+var customProperties = map[string]bool{}
+
+// Functions custom CSS values may call. url(), image-set() and friends are
+// not among them, so custom CSS cannot load anything.
+var customFunctions = map[string]bool{}
+
+func init() {
+	for _, name := range strings.Fields(`
+		color opacity cursor
+		background background-color background-image background-size background-position background-repeat
+		border border-top border-right border-bottom border-left border-color border-style border-width
+		border-radius border-top-left-radius border-top-right-radius border-bottom-left-radius border-bottom-right-radius
+		outline outline-color outline-offset outline-style outline-width
+		box-shadow text-shadow
+		font-family font-size font-style font-weight letter-spacing line-height word-spacing
+		text-align text-decoration text-decoration-color text-transform white-space
+		padding padding-top padding-right padding-bottom padding-left
+		margin margin-top margin-right margin-bottom margin-left
+		width min-width max-width height min-height max-height
+		display gap flex-direction flex-wrap align-items justify-content
+		transition transform filter backdrop-filter`) {
+		customProperties[name] = true
+	}
+	for _, name := range strings.Fields(`
+		rgb rgba hsl hsla var calc min max clamp
+		linear-gradient radial-gradient repeating-linear-gradient repeating-radial-gradient
+		translate translatex translatey rotate scale scalex scaley skew skewx skewy
+		cubic-bezier steps
+		blur brightness contrast drop-shadow grayscale hue-rotate invert saturate sepia`) {
+		customFunctions[name] = true
+	}
+}
+
+var (
+	customSelectorPattern = regexp.MustCompile(`^[a-zA-Z0-9\s.#:_\-\[\]="'>+~*(),]+$`)
+	customValuePattern    = regexp.MustCompile(`^[a-zA-Z0-9\s.#%_\-+*/,()'"!]+$`)
+	mediaPreludePattern   = regexp.MustCompile(`^[a-z0-9\s.:()\-,]+$`)
+	functionPattern       = regexp.MustCompile(`([a-zA-Z_-][a-zA-Z0-9_-]*)\s*\(`)
+)
+
+// SanitizeCustomCSS parses custom CSS and returns it re-serialized. Only
+// style rules and @media blocks of them are accepted: other at-rules
+// (@import, @font-face, ...), url(), expression() and properties outside the
+// allow-list are rejected. Pages get the rules scoped to their content, see
+// scopeCustomCSS.
+func SanitizeCustomCSS(css string) (string, error) {
+	return parseCustomCSS(css, "")
+}
+
+// scopeCustomCSS sanitizes custom CSS and prefixes every selector with the
+// scope
+func scopeCustomCSS(css string) (string, error) {
+	return parseCustomCSS(css, customCSSScope+" ")
+}
+
+func parseCustomCSS(css, scope string) (string, error) {
+	if len(css) > MaxCustomCSSLength {
+		return "", fmt.Errorf("must be at most %d characters", MaxCustomCSSLength)
+	}
+	// Escapes could spell out anything the checks below look for, and "<"
+	// could close the <style> element the CSS is served in
+	if strings.ContainsAny(css, "\\<") {
+		return "", errors.New(`must not contain "\" or "<"`)
+	}
+	css, err := stripComments(css)
+	if err != nil {
+		return "", err
+	}
+
+	p := &cssParser{src: css, scope: scope}
+	rules, err := p.rules(true)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(rules, "\n"), nil
+}
+
+type cssParser struct {
+	src   string
+	pos   int
+	scope string // Prefix of every selector
+}
+
+// rules parses style rules until the end of input or, inside @media, a
+// closing brace
+func (p *cssParser) rules(topLevel bool) ([]string, error) {
+	var out []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			if !topLevel {
+				return nil, errors.New("unclosed @media block")
+			}
+			return out, nil
+		}
+		if p.src[p.pos] == '}' {
+			if topLevel {
+				return nil, errors.New(`unexpected "}"`)
+			}
+			p.pos++
+			return out, nil
+		}
+
+		if p.src[p.pos] == '@' {
+			if !topLevel {
+				return nil, errors.New("at-rules cannot be nested")
+			}
+			rule, err := p.media()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, rule)
+			continue
+		}
+
+		rule, err := p.styleRule()
+		if err != nil {
+			return nil, err
+		}
+		if rule != "" {
+			out = append(out, rule)
+		}
+	}
+}
+
+// media parses "@media <query> { <rules> }"
+func (p *cssParser) media() (string, error) {
+	rest := strings.ToLower(p.src[p.pos:])
+	query, ok := strings.CutPrefix(rest, "@media")
+	if !ok || (query != "" && !strings.ContainsAny(query[:1], " \t\r\n(")) {
+		return "", errors.New("only @media at-rules are allowed")
+	}
+	open := strings.IndexByte(rest, '{')
+	if open < 0 {
+		return "", errors.New("@media without a block")
+	}
+	query = strings.Join(strings.Fields(rest[len("@media"):open]), " ")
+	if query == "" || !mediaPreludePattern.MatchString(query) {
+		return "", errors.New("invalid @media query")
+	}
+	p.pos += open + 1
+
+	rules, err := p.rules(false)
+	if err != nil {
+		return "", err
+	}
+	return "@media " + query + " {\n" + strings.Join(rules, "\n") + "\n}", nil
+}
+
+// styleRule parses "<selectors> { <declarations> }". Empty rules are dropped.
+func (p *cssParser) styleRule() (string, error) {
+	rest := p.src[p.pos:]
+	open := strings.IndexByte(rest, '{')
+	if open < 0 {
+		return "", errors.New(`expected "{" after selector`)
+	}
+	end := strings.IndexByte(rest[open:], '}')
+	if end < 0 {
+		return "", errors.New(`expected "}" after declarations`)
+	}
+	end += open
+	selectorText, block := rest[:open], rest[open+1:end]
+	if strings.ContainsAny(selectorText, ";}") || strings.ContainsRune(block, '{') {
+		return "", errors.New("unbalanced braces")
+	}
+	p.pos += end + 1
+
+	selectors, err := p.selectors(selectorText)
+	if err != nil {
+		return "", err
+	}
+	decls, err := sanitizeDeclarations(block)
+	if err != nil {
+		return "", err
+	}
+	if len(decls) == 0 {
+		return "", nil
+	}
+	return selectors + " { " + strings.Join(decls, " ") + " }", nil
+}
+
+func (p *cssParser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n\f", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// selectors checks a selector list and prefixes every selector with the
+// scope
+func (p *cssParser) selectors(v string) (string, error) {
+	v = strings.Join(strings.Fields(v), " ")
+	if v == "" || !customSelectorPattern.MatchString(v) {
+		return "", fmt.Errorf("invalid selector %q", v)
+	}
+	if unsafeFunction(v, pseudoClassFunctions) {
+		return "", fmt.Errorf("invalid selector %q", v)
+	}
+	selectors, ok := splitTopLevel(v, ',')
+	if !ok || !balancedQuotes(v) {
+		return "", fmt.Errorf("invalid selector %q", v)
+	}
+	for i, s := range selectors {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return "", fmt.Errorf("invalid selector %q", v)
+		}
+		selectors[i] = p.scope + s
+	}
+	return strings.Join(selectors, ", "), nil
+}
+
+// sanitizeDeclarations checks the "property: value" declarations of a block
+func sanitizeDeclarations(block string) ([]string, error) {
+	var decls []string
+	for _, decl := range strings.Split(block, ";") {
+		decl = strings.TrimSpace(decl)
+		if decl == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid declaration %q", decl)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !customProperties[name] {
+			return nil, fmt.Errorf("property %q is not allowed", name)
+		}
+		value = strings.Join(strings.Fields(value), " ")
+		if err := checkCustomValue(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		decls = append(decls, name+": "+value+";")
+	}
+	return decls, nil
+}
+
+func checkCustomValue(v string) error {
+	switch {
+	case v == "":
+		return errors.New("value is empty")
+	case len(v) > maxCustomCSSValue:
+		return fmt.Errorf("value must be at most %d characters", maxCustomCSSValue)
+	case !customValuePattern.MatchString(v) || !balancedQuotes(v):
+		return errors.New("value contains invalid characters")
+	}
+	if _, ok := splitTopLevel(v, ','); !ok {
+		return errors.New("value has unbalanced parentheses")
+	}
+	if unsafeFunction(v, customFunctions) {
+		return errors.New("value uses a function that is not allowed")
+	}
+	// "!" is only valid as !important
+	if i := strings.IndexByte(v, '!'); i >= 0 && strings.ToLower(strings.TrimSpace(v[i:])) != "!important" {
+		return errors.New(`"!" is only allowed in !important`)
+	}
+	return nil
+}
+
+// unsafeFunction reports whether v calls a function outside allowed, e.g.
+// url() or expression()
+func unsafeFunction(v string, allowed map[string]bool) bool {
+	for _, m := range functionPattern.FindAllStringSubmatch(v, -1) {
+		if !allowed[strings.ToLower(m[1])] {
+			return true
+		}
+	}
+	return false
+}
+
+// Functional pseudo-classes selectors may use
+var pseudoClassFunctions = map[string]bool{
+	"not": true, "is": true, "where": true, "nth-child": true, "nth-last-child": true,
+	"nth-of-type": true, "nth-last-of-type": true,
+}
+
+func balancedQuotes(v string) bool {
+	return strings.Count(v, `"`)%2 == 0 && strings.Count(v, `'`)%2 == 0
+}
+
+// stripComments removes /* */ comments
+func stripComments(css string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			b.WriteString(css)
+			return b.String(), nil
+		}
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return "", errors.New("unclosed comment")
+		}
+		b.WriteString(css[:start])
+		b.WriteByte(' ')
+		css = css[start+2+end+2:]
+	}
+}
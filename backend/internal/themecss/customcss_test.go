@@ -0,0 +1,122 @@
+package themecss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeCustomCSS(t *testing.T) {
+	tests := []struct {
+		name   string
+		css    string
+		want   string
+		scoped string
+	}{
+		{
+			name:   "rule",
+			css:    ".btn { color: red; }",
+			want:   ".btn { color: red; }",
+			scoped: "main .btn { color: red; }",
+		},
+		{
+			name:   "normalised",
+			css:    "a:hover,  .x > b {\n  COLOR : red ;\n  background: linear-gradient(90deg, #fff, rgba(0,0,0,.5))\n}",
+			want:   "a:hover, .x > b { color: red; background: linear-gradient(90deg, #fff, rgba(0,0,0,.5)); }",
+			scoped: "main a:hover, main .x > b { color: red; background: linear-gradient(90deg, #fff, rgba(0,0,0,.5)); }",
+		},
+		{
+			name:   "media",
+			css:    "@MEDIA (max-width: 600px) { .a { color: red } }",
+			want:   "@media (max-width: 600px) {\n.a { color: red; }\n}",
+			scoped: "@media (max-width: 600px) {\nmain .a { color: red; }\n}",
+		},
+		{
+			name:   "comments",
+			css:    "/* header */ .a { color: red /* accent */ }",
+			want:   ".a { color: red; }",
+			scoped: "main .a { color: red; }",
+		},
+		{
+			name:   "pseudo-class functions",
+			css:    "li:nth-child(2n+1):not(.x) { opacity: 0.5 !important }",
+			want:   "li:nth-child(2n+1):not(.x) { opacity: 0.5 !important; }",
+			scoped: "main li:nth-child(2n+1):not(.x) { opacity: 0.5 !important; }",
+		},
+		{
+			name:   "quoted font",
+			css:    `.a { font-family: "Comic Sans MS", cursive }`,
+			want:   `.a { font-family: "Comic Sans MS", cursive; }`,
+			scoped: `main .a { font-family: "Comic Sans MS", cursive; }`,
+		},
+		{name: "empty rule", css: ".a {}", want: "", scoped: ""},
+		{name: "empty", css: "", want: "", scoped: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeCustomCSS(tt.css)
+			if err != nil || got != tt.want {
+				t.Errorf("SanitizeCustomCSS = %q, %v, want %q", got, err, tt.want)
+			}
+			got, err = scopeCustomCSS(tt.css)
+			if err != nil || got != tt.scoped {
+				t.Errorf("scopeCustomCSS = %q, %v, want %q", got, err, tt.scoped)
+			}
+		})
+	}
+}
+
+func TestSanitizeCustomCSSRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+	}{
+		// Escapes and markup
+		{"escaped url", `.a { background: \75rl(https://evil.example/x.png) }`},
+		{"escaped property", `.a { posit\69on: fixed }`},
+		{"escaped selector", `.a\:hover { color: red }`},
+		{"closing style tag", `.a { color: red } </style><script>alert(1)</script>`},
+		{"less than in selector", `a<b { color: red }`},
+
+		// Loading resources and script
+		{"url", ".a { background: url(https://evil.example/x.png) }"},
+		{"url uppercase", ".a { background-image: URL(x.png) }"},
+		{"url with space", ".a { background-image: url (x.png) }"},
+		{"image-set", ".a { background-image: image-set('x.png' 1x) }"},
+		{"expression", ".a { width: expression(alert(1)) }"},
+		{"import", "@import 'https://evil.example/x.css';"},
+		{"font-face", "@font-face { font-family: x; src: url(x.woff) }"},
+		{"media lookalike", "@mediax { .a { color: red } }"},
+		{"nested at-rule", "@media screen { @media print { .a { color: red } } }"},
+		{"selector function", ".a:has(img) { color: red }"},
+		{"url in a later declaration", ".a { background: red;background:url(x) }"},
+
+		// Properties that can cover the page
+		{"position", ".a { position: fixed }"},
+		{"z-index", ".a { z-index: 9999 }"},
+		{"content", ".a::before { content: 'Log in here' }"},
+		{"custom property", ".a { --x: red }"},
+
+		// Malformed
+		{"unclosed comment", ".a { color: red } /*"},
+		{"unclosed block", ".a { color: red"},
+		{"unclosed media", "@media screen { .a { color: red }"},
+		{"stray brace", "} .a { color: red }"},
+		{"nested brace", ".a { color: red; .b { color: blue } }"},
+		{"no selector", "{ color: red }"},
+		{"no colon", ".a { color red }"},
+		{"empty value", ".a { color: }"},
+		{"unbalanced quote", `.a { font-family: "Arial }`},
+		{"unbalanced parentheses", ".a { width: calc(100% - 2px }"},
+		{"bang", ".a { color: red !ie }"},
+		{"semicolon in selector", ".a; .b { color: red }"},
+		{"too long", ".a { color: red }" + strings.Repeat(" ", MaxCustomCSSLength)},
+		{"value too long", ".a { font-family: " + strings.Repeat("a,", maxCustomCSSValue/2+1) + "b }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := SanitizeCustomCSS(tt.css); err == nil {
+				t.Errorf("SanitizeCustomCSS(%q) = %q, want an error", tt.css, got)
+			}
+		})
+	}
+}
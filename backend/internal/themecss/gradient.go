@@ -0,0 +1,239 @@
+package themecss
+
+import (
+	"strings"
+)
+
+// Most gradients layered in one background
+const maxGradientLayers = 3
+
+var (
+	angleUnits  = []string{"deg", "grad", "rad", "turn"}
+	lengthUnits = []string{"px", "em", "rem", "vw", "vh", "vmin", "vmax"}
+
+	sideKeywords     = map[string]bool{"left": true, "right": true, "top": true, "bottom": true}
+	positionKeywords = map[string]bool{"left": true, "right": true, "top": true, "bottom": true, "center": true}
+	radialKeywords   = map[string]bool{
+		"circle": true, "ellipse": true,
+		"closest-side": true, "closest-corner": true, "farthest-side": true, "farthest-corner": true,
+	}
+)
+
+// ParseGradient parses a background of up to three layered linear or radial
+// gradients and returns it in canonical form. Anything outside this grammar,
+// such as url() or var(), is rejected.
+func ParseGradient(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	layers, ok := splitTopLevel(v, ',')
+	if !ok || len(layers) > maxGradientLayers {
+		return "", false
+	}
+
+	out := make([]string, len(layers))
+	for i, layer := range layers {
+		gradient, ok := parseGradientLayer(strings.TrimSpace(layer))
+		if !ok {
+			return "", false
+		}
+		out[i] = gradient
+	}
+	return strings.Join(out, ", "), true
+}
+
+func parseGradientLayer(v string) (string, bool) {
+	open := strings.IndexByte(v, '(')
+	if open <= 0 || !strings.HasSuffix(v, ")") {
+		return "", false
+	}
+	name, inner := v[:open], v[open+1:len(v)-1]
+	kind := strings.TrimPrefix(name, "repeating-")
+	if kind != "linear-gradient" && kind != "radial-gradient" {
+		return "", false
+	}
+
+	args, ok := splitTopLevel(inner, ',')
+	if !ok || len(args) < 2 {
+		return "", false
+	}
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+
+	// Optional direction (linear) or shape and position (radial)
+	var parts []string
+	if _, ok := parseColorStop(args[0]); !ok {
+		var prelude string
+		if kind == "linear-gradient" {
+			prelude, ok = parseDirection(args[0])
+		} else {
+			prelude, ok = parseRadialShape(args[0])
+		}
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, prelude)
+		args = args[1:]
+	}
+
+	if len(args) < 2 {
+		return "", false
+	}
+	for _, arg := range args {
+		stop, ok := parseColorStop(arg)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, stop)
+	}
+	return name + "(" + strings.Join(parts, ", ") + ")", true
+}
+
+// parseColorStop parses "<color> [<position> [<position>]]"
+func parseColorStop(v string) (string, bool) {
+	tokens, ok := splitTopLevel(v, ' ')
+	if !ok || len(tokens) == 0 || len(tokens) > 3 {
+		return "", false
+	}
+	color, ok := ParseColor(tokens[0])
+	if !ok {
+		return "", false
+	}
+	out := []string{color}
+	for _, token := range tokens[1:] {
+		pos, ok := parseLengthPercentage(token)
+		if !ok {
+			return "", false
+		}
+		out = append(out, pos)
+	}
+	return strings.Join(out, " "), true
+}
+
+// parseDirection parses "<angle>" or "to <side> [<side>]"
+func parseDirection(v string) (string, bool) {
+	tokens := strings.Fields(v)
+	if len(tokens) == 1 {
+		return parseAngle(tokens[0], false)
+	}
+	if tokens[0] != "to" || len(tokens) > 3 {
+		return "", false
+	}
+	for _, side := range tokens[1:] {
+		if !sideKeywords[side] {
+			return "", false
+		}
+	}
+	return strings.Join(tokens, " "), true
+}
+
+// parseRadialShape parses "[<shape or extent>...] [at <position>]"
+func parseRadialShape(v string) (string, bool) {
+	tokens := strings.Fields(v)
+	if len(tokens) == 0 {
+		return "", false
+	}
+
+	i := 0
+	for ; i < len(tokens) && tokens[i] != "at"; i++ {
+		if radialKeywords[tokens[i]] {
+			continue
+		}
+		size, ok := parseLengthPercentage(tokens[i])
+		if !ok {
+			return "", false
+		}
+		tokens[i] = size
+	}
+	if i > 4 {
+		return "", false
+	}
+
+	if i < len(tokens) {
+		position := tokens[i+1:]
+		if len(position) == 0 || len(position) > 2 {
+			return "", false
+		}
+		for j, token := range position {
+			if positionKeywords[token] {
+				continue
+			}
+			pos, ok := parseLengthPercentage(token)
+			if !ok {
+				return "", false
+			}
+			position[j] = pos
+		}
+	}
+	return strings.Join(tokens, " "), true
+}
+
+// parseAngle parses an angle like "135deg". A bare number is allowed for
+// hues; otherwise only "0" may omit the unit.
+func parseAngle(v string, unitless bool) (string, bool) {
+	for _, unit := range angleUnits {
+		if n, ok := strings.CutSuffix(v, unit); ok {
+			if f, percent, ok := parseNumber(n); ok && !percent {
+				return formatNumber(f, false) + unit, true
+			}
+			return "", false
+		}
+	}
+	if f, percent, ok := parseNumber(v); ok && !percent && (unitless || f == 0) {
+		return formatNumber(f, false), true
+	}
+	return "", false
+}
+
+// parseLengthPercentage parses a percentage or a length in a common unit
+func parseLengthPercentage(v string) (string, bool) {
+	for _, unit := range lengthUnits {
+		if n, ok := strings.CutSuffix(v, unit); ok {
+			if f, percent, ok := parseNumber(n); ok && !percent {
+				return formatNumber(f, false) + unit, true
+			}
+			return "", false
+		}
+	}
+	if f, percent, ok := parseNumber(v); ok && (percent || f == 0) {
+		return formatNumber(f, percent), true
+	}
+	return "", false
+}
+
+// splitTopLevel splits v at sep outside parentheses, dropping empty parts
+// when splitting on spaces. It fails on unbalanced parentheses.
+func splitTopLevel(v string, sep byte) ([]string, bool) {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, false
+			}
+		case sep:
+			if depth == 0 {
+				parts = append(parts, v[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, false
+	}
+	parts = append(parts, v[start:])
+
+	if sep == ' ' {
+		kept := parts[:0]
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				kept = append(kept, p)
+			}
+		}
+		parts = kept
+	}
+	return parts, true
+}
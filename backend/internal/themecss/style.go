@@ -0,0 +1,213 @@
+package themecss
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+)
+
+// Family name of a theme's uploaded font
+const customFontFamily = "LinkMy Custom"
+
+// Style is the compiled presentation of a theme
+type Style struct {
+	Vars      []Var    // CSS custom properties
+	Classes   []string // Classes for <body>
+	Font      string   // Font family, one of Fonts
+	FontFace  string   // URL of the uploaded font, if any
+	CustomCSS string   // Sanitized custom rules, if any
+}
+
+// Var is a CSS custom property
+type Var struct {
+	Name  string
+	Value string
+}
+
+// Compile returns the CSS of a theme: the variables block, e.g.
+// ":root { --page-bg: #fff; ... }", followed by its custom rules
+func Compile(s *models.ThemeSettings) string {
+	return Build(s).CSS()
+}
+
+// Build compiles theme settings. Invalid values, e.g. in themes saved before
+// validation, fall back to defaults instead of failing.
+func Build(s *models.ThemeSettings) Style {
+	if s == nil {
+		s = &models.ThemeSettings{}
+	}
+
+	font, ok := canonicalFont(s.Font)
+	if !ok {
+		font = defaultFont
+	}
+
+	// In the boxed layout the outer background, if set, shows around the box
+	pageBg := background(s.BgType, s.BgValue)
+	if s.BoxedEnabled && s.BoxedOuterBgValue != nil {
+		outerType := s.BgType
+		if s.BoxedOuterBgType != nil {
+			outerType = *s.BoxedOuterBgType
+		}
+		pageBg = background(outerType, s.BoxedOuterBgValue)
+	}
+
+	// The uploaded font falls back to the chosen one while it loads
+	fontStack := "'" + font + "', system-ui, sans-serif"
+	var fontFace string
+	if s.CustomFontURL != nil {
+		if u, ok := parseImageURL(*s.CustomFontURL); ok {
+			fontFace = u
+			fontStack = "'" + customFontFamily + "', " + fontStack
+		}
+	}
+
+	// Stored rules are unscoped; sanitizing again also drops rules that a
+	// tightened allow-list no longer accepts
+	var customCSS string
+	if s.CustomCSS != nil {
+		if css, err := scopeCustomCSS(*s.CustomCSS); err == nil {
+			customCSS = css
+		}
+	}
+
+	buttonStyle := enum(s.ButtonStyle, ButtonStyles, "rounded")
+	style := Style{
+		Vars: []Var{
+			{"--page-bg", pageBg},
+			{"--btn-color", color(s.ButtonColor, defaultButton)},
+			{"--text-color", color(s.TextColor, defaultText)},
+			{"--font", fontStack},
+			{"--btn-radius", buttonRadius(buttonStyle)},
+			{"--shadow", shadow(enum(s.ShadowIntensity, ShadowIntensities, "medium"))},
+		},
+		Classes: []string{
+			"btn-" + buttonStyle,
+			"layout-" + enum(s.Layout, Layouts, "centered"),
+			"container-" + enum(s.ContainerStyle, ContainerStyles, "wide"),
+		},
+		Font:      font,
+		FontFace:  fontFace,
+		CustomCSS: customCSS,
+	}
+
+	if s.EnableAnimations {
+		style.Classes = append(style.Classes, "animated")
+	}
+	if s.EnableGlassEffect {
+		style.Classes = append(style.Classes, "glass")
+	}
+	if s.BoxedEnabled {
+		style.Classes = append(style.Classes, "boxed")
+		width := s.BoxedMaxWidth
+		if width == 0 {
+			width = defaultBoxWidth
+		}
+		style.Vars = append(style.Vars,
+			Var{"--box-bg", color(s.BoxedContainerBg, defaultContainer)},
+			Var{"--box-width", fmt.Sprintf("%dpx", max(MinBoxWidth, min(width, MaxBoxWidth)))},
+			Var{"--box-radius", fmt.Sprintf("%dpx", max(0, min(s.BoxedRadius, MaxBoxRadius)))},
+		)
+		if s.BoxedShadow {
+			style.Classes = append(style.Classes, "box-shadow")
+		}
+	}
+	return style
+}
+
+// Declarations returns the custom properties as declarations for a rule
+func (s Style) Declarations() string {
+	decls := make([]string, len(s.Vars))
+	for i, v := range s.Vars {
+		decls[i] = v.Name + ": " + v.Value + ";"
+	}
+	return strings.Join(decls, " ")
+}
+
+// CSS returns the custom properties as a :root rule, followed by the rules
+func (s Style) CSS() string {
+	css := ":root { " + s.Declarations() + " }"
+	if rules := s.Rules(); rules != "" {
+		css += "\n" + rules
+	}
+	return css
+}
+
+// Rules returns the @font-face rule of the uploaded font and the custom
+// rules. They belong after the page's own rules so they take precedence.
+func (s Style) Rules() string {
+	var rules []string
+	if s.FontFace != "" {
+		rules = append(rules, "@font-face { font-family: '"+customFontFamily+"'; src: url(\""+s.FontFace+"\"); font-display: swap; }")
+	}
+	if s.CustomCSS != "" {
+		rules = append(rules, s.CustomCSS)
+	}
+	return strings.Join(rules, "\n")
+}
+
+// FontURL returns the Google Fonts stylesheet of the font
+func (s Style) FontURL() string {
+	return "https://fonts.googleapis.com/css2?family=" + url.QueryEscape(s.Font) + ":wght@400;500;600;700&display=swap"
+}
+
+func color(v, fallback string) string {
+	if c, ok := ParseColor(v); ok {
+		return c
+	}
+	return fallback
+}
+
+// background returns the CSS background for a bg_type/bg_value pair
+func background(bgType string, bgValue *string) string {
+	if bgValue == nil {
+		return defaultBackground
+	}
+	v, ok := parseBackground(strings.ToLower(strings.TrimSpace(bgType)), *bgValue)
+	if !ok {
+		// Colors were accepted under the gradient type before validation
+		if c, ok := ParseColor(*bgValue); ok {
+			return c
+		}
+		return defaultBackground
+	}
+	if bgType == "image" {
+		return `url("` + v + `") center / cover no-repeat fixed`
+	}
+	return v
+}
+
+func enum(v string, allowed []string, fallback string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if slices.Contains(allowed, v) {
+		return v
+	}
+	return fallback
+}
+
+func buttonRadius(style string) string {
+	switch style {
+	case "pill":
+		return "999px"
+	case "square":
+		return "0"
+	case "soft":
+		return "8px"
+	}
+	return "16px"
+}
+
+func shadow(intensity string) string {
+	switch intensity {
+	case "none":
+		return "none"
+	case "light":
+		return "0 2px 8px rgba(0, 0, 0, 0.08)"
+	case "heavy":
+		return "0 10px 30px rgba(0, 0, 0, 0.3)"
+	}
+	return "0 5px 20px rgba(0, 0, 0, 0.15)"
+}
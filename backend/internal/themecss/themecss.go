@@ -0,0 +1,189 @@
+// Package themecss validates theme settings and compiles them into CSS.
+// Every value that reaches CSS is parsed against a strict grammar or an
+// allow-list and re-serialized, so stored themes cannot inject CSS into
+// pages that render them.
+package themecss
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+)
+
+// Allowed values of the enumerated theme fields
+var (
+	BgTypes           = []string{"gradient", "solid", "image"}
+	ButtonStyles      = []string{"rounded", "pill", "square", "outline", "soft"}
+	Layouts           = []string{"centered", "left"}
+	ContainerStyles   = []string{"wide", "narrow"}
+	ShadowIntensities = []string{"none", "light", "medium", "heavy"}
+)
+
+// Fonts offered by the appearance editor, loaded from Google Fonts
+var Fonts = []string{
+	"Inter", "Roboto", "Poppins", "Open Sans", "Lato", "Montserrat", "Outfit", "Nunito",
+	"Playfair Display", "Merriweather", "Space Grotesk", "DM Sans",
+}
+
+// Bounds of the boxed layout, in pixels
+const (
+	MinBoxWidth  = 320
+	MaxBoxWidth  = 1200
+	MaxBoxRadius = 64
+)
+
+// Defaults used when a stored value is missing or invalid
+const (
+	defaultBackground = "linear-gradient(135deg, #667eea 0%, #764ba2 100%)"
+	defaultButton     = "#667eea"
+	defaultText       = "#333333"
+	defaultContainer  = "#ffffff"
+	defaultFont       = "Inter"
+	defaultBoxWidth   = 680
+)
+
+const maxImageURLLength = 1000
+
+var unsafeURLChars = regexp.MustCompile(`[\s"'()\\<>;{}]`)
+
+// Validate checks theme settings and reports every invalid field. Valid
+// values are rewritten in canonical form.
+func Validate(s *models.ThemeSettings) []models.FieldError {
+	var errs []models.FieldError
+	fail := func(field, rule, message string) {
+		errs = append(errs, models.FieldError{Field: field, Rule: rule, Message: message})
+	}
+	oneOf := func(field string, value *string, allowed []string) {
+		v := strings.ToLower(strings.TrimSpace(*value))
+		if !slices.Contains(allowed, v) {
+			fail(field, "oneof", field+" must be one of: "+strings.Join(allowed, ", "))
+			return
+		}
+		*value = v
+	}
+	colorField := func(field string, value *string) {
+		c, ok := ParseColor(*value)
+		if !ok {
+			fail(field, "color", field+" must be a hex, rgb(), hsl() or named color")
+			return
+		}
+		*value = c
+	}
+	backgroundField := func(valueField string, bgType string, bgValue *string) {
+		if bgValue == nil {
+			if bgType == "image" {
+				fail(valueField, "required", valueField+" is required for image backgrounds")
+			}
+			return
+		}
+		v, ok := parseBackground(bgType, *bgValue)
+		if ok {
+			*bgValue = v
+			return
+		}
+		switch bgType {
+		case "solid":
+			fail(valueField, "color", valueField+" must be a hex, rgb(), hsl() or named color")
+		case "image":
+			fail(valueField, "image_url", valueField+" must be an http(s) image URL")
+		case "gradient":
+			fail(valueField, "gradient", valueField+" must be a linear-gradient() or radial-gradient() of colors")
+		}
+	}
+
+	oneOf("bg_type", &s.BgType, BgTypes)
+	backgroundField("bg_value", s.BgType, s.BgValue)
+	oneOf("button_style", &s.ButtonStyle, ButtonStyles)
+	colorField("button_color", &s.ButtonColor)
+	colorField("text_color", &s.TextColor)
+	if font, ok := canonicalFont(s.Font); ok {
+		s.Font = font
+	} else {
+		fail("font", "font", "font must be one of: "+strings.Join(Fonts, ", "))
+	}
+	oneOf("layout", &s.Layout, Layouts)
+	oneOf("container_style", &s.ContainerStyle, ContainerStyles)
+	oneOf("shadow_intensity", &s.ShadowIntensity, ShadowIntensities)
+
+	// Without its own value the outer background is the page background
+	outerType := s.BgType
+	if s.BoxedOuterBgType != nil {
+		oneOf("boxed_outer_bg_type", s.BoxedOuterBgType, BgTypes)
+		outerType = *s.BoxedOuterBgType
+	}
+	if s.BoxedOuterBgValue != nil {
+		backgroundField("boxed_outer_bg_value", outerType, s.BoxedOuterBgValue)
+	}
+	colorField("boxed_container_bg", &s.BoxedContainerBg)
+	if s.BoxedMaxWidth < MinBoxWidth || s.BoxedMaxWidth > MaxBoxWidth {
+		fail("boxed_max_width", "range", fmt.Sprintf("boxed_max_width must be between %d and %d", MinBoxWidth, MaxBoxWidth))
+	}
+	if s.BoxedRadius < 0 || s.BoxedRadius > MaxBoxRadius {
+		fail("boxed_radius", "range", fmt.Sprintf("boxed_radius must be between 0 and %d", MaxBoxRadius))
+	}
+
+	if s.CustomCSS != nil {
+		if css, err := SanitizeCustomCSS(*s.CustomCSS); err != nil {
+			fail("custom_css", "css", "custom_css is invalid: "+err.Error())
+		} else if css == "" {
+			s.CustomCSS = nil
+		} else {
+			s.CustomCSS = &css
+		}
+	}
+	if s.CustomFontURL != nil {
+		if u, ok := parseImageURL(*s.CustomFontURL); ok {
+			s.CustomFontURL = &u
+		} else {
+			fail("custom_font_url", "font_url", "custom_font_url must be an http(s) font URL")
+		}
+	}
+
+	return errs
+}
+
+// parseBackground parses a bg_value for its bg_type
+func parseBackground(bgType, v string) (string, bool) {
+	switch bgType {
+	case "solid":
+		return ParseColor(v)
+	case "image":
+		return parseImageURL(v)
+	case "gradient":
+		return ParseGradient(v)
+	}
+	return "", false
+}
+
+// parseImageURL accepts absolute http(s) URLs and root-relative paths
+// without characters that could end a CSS url(). Font URLs use it too.
+func parseImageURL(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" || len(v) > maxImageURLLength || unsafeURLChars.MatchString(v) {
+		return "", false
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return "", false
+	}
+	if (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" {
+		return v, true
+	}
+	if u.Scheme == "" && u.Host == "" && strings.HasPrefix(v, "/") && !strings.HasPrefix(v, "//") {
+		return v, true
+	}
+	return "", false
+}
+
+func canonicalFont(font string) (string, bool) {
+	for _, f := range Fonts {
+		if strings.EqualFold(f, strings.TrimSpace(font)) {
+			return f, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,138 @@
+// Package validation evaluates the `validate` struct tags declared on request
+// models and reports failures as per-field errors.
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/FahmiYoshikage/linkmy-v2/internal/linkurl"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/models"
+	"github.com/FahmiYoshikage/linkmy-v2/internal/slugpolicy"
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names, or query parameter names
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name, _, _ = strings.Cut(field.Tag.Get("query"), ",")
+		}
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// Destination URLs of links (see package linkurl)
+	v.RegisterValidation("linkurl", func(fl validator.FieldLevel) bool {
+		_, err := linkurl.Normalize(fl.Field().String())
+		return err == nil
+	})
+
+	// Profile slugs (see package slugpolicy)
+	v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		_, err := slugpolicy.Normalize(fl.Field().String())
+		return err == nil
+	})
+
+	return v
+}
+
+// Struct validates s and returns one error per failed field, or nil if s is valid
+func Struct(s interface{}) []models.FieldError {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []models.FieldError{{Field: "", Rule: "invalid", Message: "Request is invalid"}}
+	}
+
+	fields := make([]models.FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, toFieldError(fe))
+	}
+	return fields
+}
+
+func toFieldError(fe validator.FieldError) models.FieldError {
+	// Drop the struct name: "CreateLinkRequest.url" -> "url"
+	field := fe.Namespace()
+	if _, rest, ok := strings.Cut(field, "."); ok {
+		field = rest
+	}
+
+	// Link URLs carry a more specific reason than "invalid URL"
+	if fe.Tag() == "linkurl" {
+		if _, err := linkurl.Normalize(fe.Value().(string)); err != nil {
+			var urlErr *linkurl.Error
+			if errors.As(err, &urlErr) {
+				return models.FieldError{Field: field, Rule: urlErr.Rule, Message: urlErr.Message}
+			}
+		}
+	}
+
+	// Slugs report which part of the slug policy they break
+	if fe.Tag() == "slug" {
+		if _, err := slugpolicy.Normalize(fe.Value().(string)); err != nil {
+			var slugErr *slugpolicy.Error
+			if errors.As(err, &slugErr) {
+				return models.FieldError{Field: field, Rule: slugErr.Rule, Message: slugErr.Message}
+			}
+		}
+	}
+
+	return models.FieldError{
+		Field:   field,
+		Rule:    fe.Tag(),
+		Message: field + " " + message(fe),
+	}
+}
+
+// message describes a failed rule in plain language
+func message(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "max":
+		bound := "at least "
+		if fe.Tag() == "max" {
+			bound = "at most "
+		}
+		switch fe.Kind() {
+		case reflect.String:
+			if fe.Tag() == "min" && param == "1" {
+				return "must not be empty"
+			}
+			return "must be " + bound + param + " characters"
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return "must contain " + bound + param + " items"
+		}
+		return "must be " + bound + param
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "may only contain letters and numbers"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(param, " ", ", ")
+	case "hexcolor":
+		return "must be a hex color such as #667eea"
+	case "url", "linkurl":
+		return "must be a valid URL"
+	case "len":
+		return "must be exactly " + param + " characters"
+	case "numeric":
+		return "must contain only digits"
+	}
+	return "is invalid"
+}
@@ -14,6 +14,15 @@ interface User {
 	email: string;
 	is_verified: boolean;
 	created_at: string;
+	impersonation?: Impersonation;
+}
+
+// Set on /me when staff are signed in as the user
+interface Impersonation {
+	actor_id: number;
+	actor_username: string;
+	read_only: boolean;
+	expires_at: string;
 }
 
 interface AuthResponse {
@@ -289,4 +298,4 @@ export const themes = {
 	}
 };
 
-export type { User, Profile, Link, Category, Theme, PublicProfile, AuthResponse, ApiResponse };
+export type { User, Impersonation, Profile, Link, Category, Theme, PublicProfile, AuthResponse, ApiResponse };